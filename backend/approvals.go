@@ -0,0 +1,481 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Status de uma etapa de aprovação
+const (
+    StepPending   = "pendente"
+    StepApproved  = "aprovado"
+    StepRejected  = "rejeitado"
+    StepDiscarded = "descartado" // Etapa invalidada (cancelamento ou reinício da cadeia)
+)
+
+// Tipos de aprovador aceitos na política
+const (
+    approverManager    = "manager"
+    approverRolePrefix = "role:"
+)
+
+// Etapa configurável da política de aprovação
+type ApprovalPolicyStep struct {
+    Name              string `json:"name"`
+    Approver          string `json:"approver"`                     // "manager" (gestor direto) ou "role:<papel>"
+    MinEstimatedCost  int64  `json:"min_estimated_cost,omitempty"` // Exige a etapa a partir deste custo (centavos)
+    InternationalOnly bool   `json:"international_only,omitempty"` // Exige a etapa apenas em viagens internacionais
+}
+
+type ApprovalPolicy struct {
+    Steps []ApprovalPolicyStep `json:"steps"`
+}
+
+// Política padrão: gestor direto, financeiro acima de R$ 5.000,00 e diretoria
+// para destinos internacionais. Pode ser substituída via APPROVAL_POLICY_FILE.
+var approvalPolicy = ApprovalPolicy{
+    Steps: []ApprovalPolicyStep{
+        {Name: "Gestor direto", Approver: approverManager},
+        {Name: "Financeiro", Approver: approverRolePrefix + RoleFinance, MinEstimatedCost: 500000},
+        {Name: "Diretoria", Approver: approverRolePrefix + RoleDirector, InternationalOnly: true},
+    },
+}
+
+// Etapa da cadeia de aprovação de um pedido
+type ApprovalStep struct {
+    ID              uint           `json:"id" gorm:"primaryKey"`
+    TravelRequestID uint           `json:"travel_request_id" gorm:"index"`
+    TravelRequest   *TravelRequest `json:"travel_request,omitempty" gorm:"foreignKey:TravelRequestID"`
+    Position        int            `json:"position"`
+    Name            string         `json:"name"`
+    ApproverID      *uint          `json:"approver_id"`   // Aprovador específico (gestor direto)
+    ApproverRole    string         `json:"approver_role"` // Ou qualquer usuário com este papel
+    Status          string         `json:"status" gorm:"default:'pendente'"`
+    DecidedByID     *uint          `json:"decided_by_id"`
+    OnBehalfOfID    *uint          `json:"on_behalf_of_id"` // Aprovador ausente, quando decidida por substituto
+    DecidedAt       *time.Time     `json:"decided_at"`
+    Comment         string         `json:"comment"`
+    CreatedAt       time.Time      `json:"created_at"`
+}
+
+type ApprovalDecisionRequest struct {
+    Comment string `json:"comment"`
+}
+
+// loadApprovalPolicy carrega a política de APPROVAL_POLICY_FILE, se definida
+func loadApprovalPolicy() {
+    path := getEnv("APPROVAL_POLICY_FILE", "")
+    if path == "" {
+        return
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        log.Fatal("Falha ao ler política de aprovação:", err)
+    }
+
+    var policy ApprovalPolicy
+    if err := json.Unmarshal(data, &policy); err != nil {
+        log.Fatal("Política de aprovação inválida:", err)
+    }
+    if err := policy.validate(); err != nil {
+        log.Fatal("Política de aprovação inválida:", err)
+    }
+
+    approvalPolicy = policy
+    print_status(fmt.Sprintf("Política de aprovação carregada de %s (%d etapas)", path, len(policy.Steps)))
+}
+
+func (p ApprovalPolicy) validate() error {
+    for i, step := range p.Steps {
+        if step.Name == "" {
+            return fmt.Errorf("etapa %d sem nome", i+1)
+        }
+        if step.Approver == approverManager {
+            continue
+        }
+        role := strings.TrimPrefix(step.Approver, approverRolePrefix)
+        if !strings.HasPrefix(step.Approver, approverRolePrefix) || !validRoles[role] {
+            return fmt.Errorf("aprovador inválido na etapa %q: %s", step.Name, step.Approver)
+        }
+    }
+    return nil
+}
+
+// applies indica se a etapa da política é exigida para o pedido
+func (s ApprovalPolicyStep) applies(request TravelRequest) bool {
+    if s.MinEstimatedCost > 0 && request.EstimatedCost < s.MinEstimatedCost {
+        return false
+    }
+    if s.InternationalOnly && !request.International {
+        return false
+    }
+    return true
+}
+
+// syncApprovalChain descarta as etapas pendentes do pedido e, se ele estiver
+// aguardando aprovação, monta uma nova cadeia a partir da política vigente e
+// avisa os aprovadores da primeira etapa. Deve ser chamada na mesma transação
+// de toda mudança de status.
+func syncApprovalChain(tx *gorm.DB, request TravelRequest, actorID uint) error {
+    err := tx.Model(&ApprovalStep{}).
+        Where("travel_request_id = ? AND status = ?", request.ID, StepPending).
+        Update("status", StepDiscarded).Error
+    if err != nil {
+        return err
+    }
+
+    if request.Status != StatusRequested {
+        return nil
+    }
+
+    var requester User
+    if err := tx.Where("id = ?", request.TravelerID).First(&requester).Error; err != nil {
+        return err
+    }
+
+    position := 0
+    for _, policyStep := range approvalPolicy.Steps {
+        if !policyStep.applies(request) {
+            continue
+        }
+
+        position++
+        step := ApprovalStep{
+            TravelRequestID: request.ID,
+            Position:        position,
+            Name:            policyStep.Name,
+            Status:          StepPending,
+        }
+
+        if policyStep.Approver == approverManager {
+            // Sem gestor cadastrado, qualquer aprovador pode assumir a etapa
+            if requester.ManagerID != nil {
+                step.ApproverID = requester.ManagerID
+            } else {
+                step.ApproverRole = RoleApprover
+            }
+        } else {
+            step.ApproverRole = strings.TrimPrefix(policyStep.Approver, approverRolePrefix)
+        }
+
+        if err := tx.Create(&step).Error; err != nil {
+            return err
+        }
+    }
+
+    return enqueueApprovalRequested(tx, request, actorID)
+}
+
+// enqueueApprovalRequested avisa os responsáveis pela etapa atual do pedido
+// (evento approval.requested). Sem etapa pendente, não faz nada.
+func enqueueApprovalRequested(tx *gorm.DB, request TravelRequest, actorID uint) error {
+    var step ApprovalStep
+    err := tx.Where("travel_request_id = ? AND status = ?", request.ID, StepPending).
+        Order("position ASC").First(&step).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    return enqueueEvent(tx, TravelRequestEvent{
+        Type:     EventApprovalRequested,
+        Request:  request,
+        ActorID:  actorID,
+        StepName: step.Name,
+    })
+}
+
+// resetApprovalChain descarta inclusive as etapas já aprovadas e monta a
+// cadeia novamente, usada quando campos materiais do pedido mudam
+func resetApprovalChain(tx *gorm.DB, request TravelRequest, actorID uint) error {
+    err := tx.Model(&ApprovalStep{}).
+        Where("travel_request_id = ? AND status IN ?", request.ID, []string{StepPending, StepApproved}).
+        Update("status", StepDiscarded).Error
+    if err != nil {
+        return err
+    }
+    return syncApprovalChain(tx, request, actorID)
+}
+
+// currentApprovalStep retorna a próxima etapa pendente da cadeia do pedido
+func currentApprovalStep(requestID uint) (ApprovalStep, bool) {
+    var step ApprovalStep
+    err := db.Where("travel_request_id = ? AND status = ?", requestID, StepPending).
+        Order("position ASC").First(&step).Error
+    return step, err == nil
+}
+
+// decidableRoles lista os papéis de etapa que o usuário pode decidir
+func decidableRoles(user User) []string {
+    roles := []string{user.Role}
+    if hasRole(user.Role, approverRoles...) {
+        roles = append(roles, RoleApprover)
+    }
+    return roles
+}
+
+// canDecideStep indica se o usuário pode decidir a etapa. Admin sempre pode.
+func canDecideStep(step ApprovalStep, user User) bool {
+    if user.Role == RoleAdmin {
+        return true
+    }
+    if step.ApproverID != nil {
+        return *step.ApproverID == user.ID
+    }
+    if step.ApproverRole == RoleApprover {
+        return hasRole(user.Role, approverRoles...)
+    }
+    return hasRole(user.Role, step.ApproverRole)
+}
+
+// decideApprovalStep aprova ou rejeita a etapa atual do pedido. A rejeição
+// encerra a cadeia e o pedido só chega a "aprovado" após a última etapa.
+func decideApprovalStep(c *gin.Context, request TravelRequest, step ApprovalStep, approve bool, comment string) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    if request.Status != StatusRequested || step.Status != StepPending {
+        c.JSON(http.StatusConflict, gin.H{
+            "error":               "Pedido não está aguardando aprovação",
+            "current_status":      request.Status,
+            "allowed_transitions": nextStatuses(request.Status),
+        })
+        return
+    }
+
+    if current, ok := currentApprovalStep(request.ID); !ok || current.ID != step.ID {
+        c.JSON(http.StatusConflict, gin.H{"error": "Esta etapa ainda não é a próxima da cadeia de aprovação"})
+        return
+    }
+
+    // REGRA DE NEGÓCIO: Usuário que criou o pedido NÃO pode aprová-lo ou rejeitá-lo
+    if isRequestOwner(request, user.ID) {
+        c.JSON(http.StatusForbidden, gin.H{
+            "error": "Você não pode alterar o status de um pedido que você mesmo criou. Outro usuário deve fazer essa alteração.",
+        })
+        return
+    }
+
+    delegators, err := activeDelegators(db, user.ID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar substituições"})
+        return
+    }
+    onBehalfOf, ok := stepDecider(step, user, delegators)
+    if !ok {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Permissão insuficiente: você não é o aprovador desta etapa"})
+        return
+    }
+    // A mesma regra vale para o aprovador substituído
+    if onBehalfOf != nil && isRequestOwner(request, onBehalfOf.ID) {
+        c.JSON(http.StatusForbidden, gin.H{
+            "error": "Como substituto, você não pode alterar o status de um pedido criado pelo próprio aprovador substituído.",
+        })
+        return
+    }
+
+    now := time.Now()
+    step.DecidedByID = &user.ID
+    if onBehalfOf != nil {
+        step.OnBehalfOfID = &onBehalfOf.ID
+    }
+    step.DecidedAt = &now
+    step.Comment = comment
+    step.Status = StepApproved
+    if !approve {
+        step.Status = StepRejected
+    }
+
+    oldStatus := request.Status
+    err = db.Transaction(func(tx *gorm.DB) error {
+        // UPDATE condicional: duas decisões simultâneas sobre a mesma etapa não se sobrepõem
+        result := tx.Model(&step).Where("status = ?", StepPending).Select("status", "decided_by_id", "on_behalf_of_id", "decided_at", "comment").Updates(&step)
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return errVersionConflict
+        }
+
+        // Cada aprovação reavalia a política: regras hard bloqueiam e os alertas são atualizados
+        if approve {
+            if err := applyTravelPolicy(&request); err != nil {
+                return err
+            }
+            if err := checkTripOverlaps(tx, &request); err != nil {
+                return err
+            }
+        }
+
+        var remaining int64
+        if err := tx.Model(&ApprovalStep{}).Where("travel_request_id = ? AND status = ?", request.ID, StepPending).Count(&remaining).Error; err != nil {
+            return err
+        }
+
+        switch {
+        case !approve:
+            request.Status = StatusRejected
+        case remaining == 0:
+            // Última etapa: o orçamento do centro de custo pode recusar ou escalar
+            escalated, err := enforceBudget(tx, request)
+            if err != nil {
+                return err
+            }
+            if !escalated {
+                request.Status = StatusApproved
+            }
+        }
+
+        // Toda decisão incrementa a versão do pedido, mesmo sem mudar o status
+        if err := saveTravelRequest(tx, &request); err != nil {
+            return err
+        }
+        if request.Status == oldStatus {
+            // Etapa aprovada com outras pela frente: avisa a próxima
+            return enqueueApprovalRequested(tx, request, user.ID)
+        }
+        if err := recordStatusChangeOnBehalf(tx, request, oldStatus, user.ID, step.OnBehalfOfID, comment); err != nil {
+            return err
+        }
+        err := enqueueEvent(tx, TravelRequestEvent{
+            Type:       EventRequestStatusChanged,
+            Request:    request,
+            FromStatus: oldStatus,
+            ActorID:    user.ID,
+            Reason:     comment,
+        })
+        if err != nil {
+            return err
+        }
+        return syncApprovalChain(tx, request, user.ID)
+    })
+    if err != nil {
+        if !respondBudgetExceeded(c, err) && !respondPolicyViolation(c, err) && !respondTripOverlap(c, err) {
+            respondSaveError(c, err, "Erro ao registrar decisão de aprovação")
+        }
+        return
+    }
+
+    decidedBy := user.Email
+    if onBehalfOf != nil {
+        decidedBy = fmt.Sprintf("%s em nome de %s", user.Email, onBehalfOf.Email)
+    }
+    print_status(fmt.Sprintf("Etapa '%s' do pedido %d %s por %s", step.Name, request.ID, step.Status, decidedBy))
+
+    setETag(c, request)
+    c.JSON(http.StatusOK, request)
+}
+
+func listApprovalStepsHandler(c *gin.Context) {
+    request, err := findVisibleTravelRequest(c, c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
+        return
+    }
+
+    var steps []ApprovalStep
+    db.Where("travel_request_id = ?", request.ID).Order("id ASC").Find(&steps)
+
+    if steps == nil {
+        steps = []ApprovalStep{}
+    }
+
+    c.JSON(http.StatusOK, steps)
+}
+
+func pendingApprovalsHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    // Apenas a primeira etapa pendente de cada pedido aguarda decisão
+    query := db.Preload("TravelRequest").
+        Joins("JOIN travel_requests ON travel_requests.id = approval_steps.travel_request_id").
+        Where("approval_steps.status = ? AND travel_requests.status = ?", StepPending, StatusRequested).
+        Where("NOT EXISTS (SELECT 1 FROM approval_steps prev WHERE prev.travel_request_id = approval_steps.travel_request_id AND prev.status = ? AND prev.position < approval_steps.position)", StepPending).
+        Where("travel_requests.user_id <> ? AND travel_requests.created_by_id <> ? AND travel_requests.traveler_id <> ?", user.ID, user.ID, user.ID).
+        Where("travel_requests.id IN (?)", visibleTravelRequests(c).Select("travel_requests.id"))
+
+    // Etapas do próprio usuário e dos aprovadores ausentes que ele substitui
+    delegators, err := activeDelegators(db, user.ID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar substituições"})
+        return
+    }
+    routed := db.Where("1 = 0")
+    decidesAll := false
+    for _, decider := range append([]User{user}, delegators...) {
+        decidesAll = decidesAll || decider.Role == RoleAdmin
+        routed = routed.Or("approval_steps.approver_id = ? OR (approval_steps.approver_id IS NULL AND approval_steps.approver_role IN ?)", decider.ID, decidableRoles(decider))
+    }
+    if !decidesAll {
+        query = query.Where(routed)
+    }
+
+    var found []ApprovalStep
+    query.Order("approval_steps.created_at ASC").Find(&found)
+
+    // Como substituto, o usuário não decide pedidos do próprio aprovador substituído
+    steps := []ApprovalStep{}
+    for _, step := range found {
+        onBehalfOf, ok := stepDecider(step, user, delegators)
+        if ok && (onBehalfOf == nil || !isRequestOwner(*step.TravelRequest, onBehalfOf.ID)) {
+            steps = append(steps, step)
+        }
+    }
+
+    c.JSON(http.StatusOK, steps)
+}
+
+func approveStepHandler(c *gin.Context) {
+    decideStepHandler(c, true)
+}
+
+func rejectStepHandler(c *gin.Context) {
+    decideStepHandler(c, false)
+}
+
+func decideStepHandler(c *gin.Context, approve bool) {
+    request, err := findVisibleTravelRequest(c, c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
+        return
+    }
+
+    var step ApprovalStep
+    if err := db.Where("id = ? AND travel_request_id = ?", c.Param("step_id"), request.ID).First(&step).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Etapa de aprovação não encontrada"})
+        return
+    }
+
+    if !checkIfMatch(c, request) {
+        return
+    }
+
+    var req ApprovalDecisionRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    decideApprovalStep(c, request, step, approve, req.Comment)
+}
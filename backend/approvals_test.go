@@ -0,0 +1,131 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// setupApprovalUsers cria admin, solicitante com gestor direto, financeiro e diretoria
+func setupApprovalUsers() (map[string]string, *gin.Engine) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    tokens := map[string]string{
+        "admin":     registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin),
+        "manager":   registerAndLogin(router, "Manager", "manager@example.com", RoleManager),
+        "requester": registerAndLogin(router, "Requester", "requester@example.com", ""),
+        "finance":   registerAndLogin(router, "Finance", "finance@example.com", RoleFinance),
+        "director":  registerAndLogin(router, "Director", "director@example.com", RoleDirector),
+    }
+    
+    managerID := uint(2)
+    db.Model(&User{}).Where("email = ?", "requester@example.com").Update("manager_id", managerID)
+    
+    return tokens, router
+}
+
+func createCostlyTravelRequest(router *gin.Engine, token string) uint {
+    w := performRequest(router, "POST", "/api/travel-requests", token, CreateTravelRequest{
+        Destination:   "Lisboa",
+        DepartureDate: "2025-09-01",
+        ReturnDate:    "2025-09-10",
+        EstimatedCost: 800000,
+        International: true,
+    })
+    
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    
+    return created.ID
+}
+
+func TestApprovalChainRequiresAllSteps(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    id := createCostlyTravelRequest(router, tokens["requester"])
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    
+    var steps []ApprovalStep
+    w := performRequest(router, "GET", path+"/approvals", tokens["requester"], nil)
+    json.Unmarshal(w.Body.Bytes(), &steps)
+    assert.Len(t, steps, 3)
+    assert.Equal(t, uint(2), *steps[0].ApproverID)
+    assert.Equal(t, RoleFinance, steps[1].ApproverRole)
+    assert.Equal(t, RoleDirector, steps[2].ApproverRole)
+    
+    // Financeiro ainda não pode decidir: a etapa do gestor vem antes
+    w = performConditional(router, "POST", fmt.Sprintf("%s/approvals/%d/approve", path, steps[1].ID), tokens["finance"], "*", nil)
+    assert.Equal(t, 409, w.Code)
+    
+    w = performRequest(router, "GET", "/api/approvals/pending", tokens["manager"], nil)
+    assert.Contains(t, w.Body.String(), "Gestor direto")
+    
+    w = performConditional(router, "PUT", path+"/status", tokens["manager"], "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 200, w.Code)
+    assert.Contains(t, w.Body.String(), StatusRequested)
+    
+    w = performConditional(router, "POST", fmt.Sprintf("%s/approvals/%d/approve", path, steps[1].ID), tokens["finance"], "*", ApprovalDecisionRequest{Comment: "Ok"})
+    assert.Equal(t, 200, w.Code)
+    assert.Contains(t, w.Body.String(), StatusRequested)
+    
+    // Somente a diretoria pode decidir a última etapa
+    w = performConditional(router, "POST", fmt.Sprintf("%s/approvals/%d/approve", path, steps[2].ID), tokens["finance"], "*", nil)
+    assert.Equal(t, 403, w.Code)
+    
+    w = performRequest(router, "GET", "/api/approvals/pending", tokens["director"], nil)
+    assert.Contains(t, w.Body.String(), "Diretoria")
+    
+    w = performConditional(router, "POST", fmt.Sprintf("%s/approvals/%d/approve", path, steps[2].ID), tokens["director"], "*", nil)
+    assert.Equal(t, 200, w.Code)
+    assert.Contains(t, w.Body.String(), StatusApproved)
+}
+
+func TestApprovalChainRejectionDiscardsRemainingSteps(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    id := createCostlyTravelRequest(router, tokens["requester"])
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    
+    w := performConditional(router, "PUT", path+"/status", tokens["manager"], "*", UpdateStatusRequest{Status: StatusRejected, Reason: "Sem verba"})
+    assert.Equal(t, 200, w.Code)
+    assert.Contains(t, w.Body.String(), StatusRejected)
+    
+    var steps []ApprovalStep
+    db.Where("travel_request_id = ?", id).Order("position ASC").Find(&steps)
+    assert.Equal(t, StepRejected, steps[0].Status)
+    assert.Equal(t, "Sem verba", steps[0].Comment)
+    assert.Equal(t, StepDiscarded, steps[1].Status)
+    assert.Equal(t, StepDiscarded, steps[2].Status)
+    
+    w = performRequest(router, "GET", "/api/approvals/pending", tokens["finance"], nil)
+    assert.Equal(t, "[]", w.Body.String())
+}
+
+func TestApprovalChainSkipsStepsThatDoNotApply(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    id := createTestTravelRequest(router, tokens["requester"], "Campinas")
+    
+    var steps []ApprovalStep
+    db.Where("travel_request_id = ?", id).Find(&steps)
+    assert.Len(t, steps, 1)
+    
+    w := performConditional(router, "PUT", fmt.Sprintf("/api/travel-requests/%d/status", id), tokens["manager"], "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 200, w.Code)
+    assert.Contains(t, w.Body.String(), StatusApproved)
+}
+
+func TestApprovalPolicyValidation(t *testing.T) {
+    valid := ApprovalPolicy{Steps: []ApprovalPolicyStep{
+        {Name: "Gestor", Approver: "manager"},
+        {Name: "Financeiro", Approver: "role:finance", MinEstimatedCost: 100},
+    }}
+    assert.NoError(t, valid.validate())
+    
+    invalid := ApprovalPolicy{Steps: []ApprovalPolicyStep{{Name: "CEO", Approver: "role:ceo"}}}
+    assert.Error(t, invalid.validate())
+}
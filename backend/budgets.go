@@ -0,0 +1,405 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// Modos de controle do orçamento na aprovação final
+const (
+    BudgetBlock    = "block"    // recusa a aprovação que estouraria o orçamento
+    BudgetEscalate = "escalate" // exige uma etapa extra de aprovação
+)
+
+// Nome da etapa criada quando a aprovação estouraria o orçamento
+const budgetEscalationStep = "Orçamento excedido"
+
+var (
+    budgetEnforcement    = BudgetBlock
+    budgetEscalationRole = RoleDirector
+)
+
+// Status cujo custo estimado já consome o orçamento
+var budgetConsumingStatuses = []string{StatusApproved, StatusTraveling, StatusCompleted}
+
+// Orçamento de um centro de custo em um período. O consumo é calculado a
+// partir dos pedidos com ida dentro do período.
+type Budget struct {
+    ID          uint      `json:"id" gorm:"primaryKey"`
+    CostCenter  string    `json:"cost_center" gorm:"index"`
+    PeriodStart time.Time `json:"period_start"`
+    PeriodEnd   time.Time `json:"period_end"` // Inclusivo
+    Amount      int64     `json:"amount"`     // Centavos em reais
+    CreatedByID uint      `json:"created_by_id"`
+    CreatedAt   time.Time `json:"created_at"`
+    UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type BudgetInput struct {
+    CostCenter  string `json:"cost_center" binding:"required"`
+    PeriodStart string `json:"period_start" binding:"required"`
+    PeriodEnd   string `json:"period_end" binding:"required"`
+    Amount      int64  `json:"amount" binding:"gt=0"` // Centavos
+}
+
+// Situação do orçamento no momento da consulta
+type BudgetSummary struct {
+    Budget
+    Consumed    int64   `json:"consumed"`    // Aprovados, em viagem e concluídos
+    Pending     int64   `json:"pending"`     // Aguardando aprovação
+    Available   int64   `json:"available"`   // Amount - Consumed (negativo se estourado)
+    Utilization float64 `json:"utilization"` // Consumed / Amount
+}
+
+// Devolvido pela aprovação quando o pedido estouraria o orçamento no modo block
+type budgetExceededError struct {
+    Summary BudgetSummary
+    Cost    int64
+}
+
+func (e *budgetExceededError) Error() string {
+    return fmt.Sprintf("orçamento do centro de custo %s excedido", e.Summary.CostCenter)
+}
+
+// loadBudgetConfig lê o modo de controle de orçamento do ambiente
+func loadBudgetConfig() {
+    switch mode := getEnv("BUDGET_ENFORCEMENT", BudgetBlock); mode {
+    case BudgetBlock, BudgetEscalate:
+        budgetEnforcement = mode
+    default:
+        print_status(fmt.Sprintf("BUDGET_ENFORCEMENT inválido (%q); usando %s", mode, BudgetBlock))
+    }
+    if role := getEnv("BUDGET_ESCALATION_ROLE", ""); role != "" && validRoles[role] {
+        budgetEscalationRole = role
+    }
+}
+
+// findBudgetFor busca o orçamento do centro de custo vigente na data
+func findBudgetFor(tx *gorm.DB, costCenter string, date time.Time) (Budget, bool, error) {
+    var budget Budget
+    err := tx.Where("cost_center = ? AND period_start <= ? AND period_end >= ?", costCenter, date, date).
+        First(&budget).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return budget, false, nil
+    }
+    return budget, err == nil, err
+}
+
+// summarizeBudget soma o custo estimado dos pedidos do centro de custo no período
+func summarizeBudget(tx *gorm.DB, budget Budget) (BudgetSummary, error) {
+    summary := BudgetSummary{Budget: budget}
+
+    inPeriod := func() *gorm.DB {
+        return tx.Model(&TravelRequest{}).
+            Select("COALESCE(SUM(estimated_cost), 0)").
+            Where("cost_center = ? AND departure_date >= ? AND departure_date < ?",
+                budget.CostCenter, budget.PeriodStart, budget.PeriodEnd.AddDate(0, 0, 1))
+    }
+    if err := inPeriod().Where("status IN ?", budgetConsumingStatuses).Scan(&summary.Consumed).Error; err != nil {
+        return summary, err
+    }
+    if err := inPeriod().Where("status = ?", StatusRequested).Scan(&summary.Pending).Error; err != nil {
+        return summary, err
+    }
+
+    summary.Available = budget.Amount - summary.Consumed
+    if budget.Amount > 0 {
+        summary.Utilization = float64(summary.Consumed) / float64(budget.Amount)
+    }
+    return summary, nil
+}
+
+// enforceBudget é chamada antes do pedido chegar a "aprovado". Se o custo
+// estouraria o orçamento, recusa (block) ou acrescenta uma etapa de aprovação
+// (escalate), retornando true. Uma etapa de orçamento já aprovada libera o pedido.
+func enforceBudget(tx *gorm.DB, request TravelRequest) (bool, error) {
+    if request.CostCenter == "" || request.EstimatedCost == 0 {
+        return false, nil
+    }
+
+    var overridden int64
+    err := tx.Model(&ApprovalStep{}).
+        Where("travel_request_id = ? AND name = ? AND status = ?", request.ID, budgetEscalationStep, StepApproved).
+        Count(&overridden).Error
+    if err != nil || overridden > 0 {
+        return false, err
+    }
+
+    // Trava o orçamento até o fim da transação: aprovações simultâneas do
+    // mesmo centro de custo são conferidas uma de cada vez
+    budget, ok, err := findBudgetFor(tx.Clauses(clause.Locking{Strength: "UPDATE"}), request.CostCenter, request.DepartureDate)
+    if err != nil || !ok {
+        return false, err
+    }
+    summary, err := summarizeBudget(tx, budget)
+    if err != nil {
+        return false, err
+    }
+    if summary.Consumed+request.EstimatedCost <= budget.Amount {
+        return false, nil
+    }
+
+    if budgetEnforcement != BudgetEscalate {
+        return false, &budgetExceededError{Summary: summary, Cost: request.EstimatedCost}
+    }
+
+    var last ApprovalStep
+    if err := tx.Where("travel_request_id = ?", request.ID).Order("position DESC").Limit(1).Find(&last).Error; err != nil {
+        return false, err
+    }
+    step := ApprovalStep{
+        TravelRequestID: request.ID,
+        Position:        last.Position + 1,
+        Name:            budgetEscalationStep,
+        ApproverRole:    budgetEscalationRole,
+        Status:          StepPending,
+    }
+    if err := tx.Create(&step).Error; err != nil {
+        return false, err
+    }
+    print_status(fmt.Sprintf("Pedido %d estouraria o orçamento de %s; aprovação escalada para %s",
+        request.ID, request.CostCenter, budgetEscalationRole))
+    return true, nil
+}
+
+// errCostCenterNotAllowed indica um centro de custo de outra equipe
+var errCostCenterNotAllowed = errors.New("Centro de custo deve ser a equipe do viajante ou de quem cria o pedido")
+
+// validateCostCenter restringe o centro de custo à equipe do viajante ou de
+// quem cria o pedido. Financeiro e admin podem alocar em qualquer centro de
+// custo conhecido (equipe de algum usuário ou com orçamento cadastrado).
+func validateCostCenter(actor, traveler User, costCenter string) error {
+    if costCenter == traveler.Department || costCenter == actor.Department {
+        return nil
+    }
+    if !hasRole(actor.Role, RoleFinance) {
+        return errCostCenterNotAllowed
+    }
+
+    var known int64
+    err := db.Model(&User{}).Where("department = ?", costCenter).Count(&known).Error
+    if err == nil && known == 0 {
+        err = db.Model(&Budget{}).Where("cost_center = ?", costCenter).Count(&known).Error
+    }
+    if err != nil {
+        return err
+    }
+    if known == 0 {
+        return fmt.Errorf("Centro de custo desconhecido: %s", costCenter)
+    }
+    return nil
+}
+
+// respondCostCenterError responde 403 para centro de custo de outra equipe e 400 nos demais casos
+func respondCostCenterError(c *gin.Context, err error) {
+    status := http.StatusBadRequest
+    if errors.Is(err, errCostCenterNotAllowed) {
+        status = http.StatusForbidden
+    }
+    c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// respondBudgetExceeded traduz a recusa por orçamento em 409 com a situação atual
+func respondBudgetExceeded(c *gin.Context, err error) bool {
+    var exceeded *budgetExceededError
+    if !errors.As(err, &exceeded) {
+        return false
+    }
+    c.JSON(http.StatusConflict, gin.H{
+        "error":  fmt.Sprintf("Aprovação recusada: o pedido estouraria o orçamento do centro de custo %s", exceeded.Summary.CostCenter),
+        "cost":   exceeded.Cost,
+        "budget": exceeded.Summary,
+    })
+    return true
+}
+
+// parseBudgetInput valida o período (YYYY-MM-DD) e monta o orçamento
+func parseBudgetInput(req BudgetInput) (Budget, error) {
+    start, err := time.Parse("2006-01-02", req.PeriodStart)
+    if err != nil {
+        return Budget{}, fmt.Errorf("Formato de início do período inválido (use YYYY-MM-DD)")
+    }
+    end, err := time.Parse("2006-01-02", req.PeriodEnd)
+    if err != nil {
+        return Budget{}, fmt.Errorf("Formato de fim do período inválido (use YYYY-MM-DD)")
+    }
+    if end.Before(start) {
+        return Budget{}, fmt.Errorf("Fim do período deve ser posterior ao início")
+    }
+    return Budget{CostCenter: req.CostCenter, PeriodStart: start, PeriodEnd: end, Amount: req.Amount}, nil
+}
+
+// budgetOverlaps indica se já existe outro orçamento do centro de custo no período
+func budgetOverlaps(budget Budget) bool {
+    var count int64
+    db.Model(&Budget{}).
+        Where("cost_center = ? AND id <> ? AND period_start <= ? AND period_end >= ?",
+            budget.CostCenter, budget.ID, budget.PeriodEnd, budget.PeriodStart).
+        Count(&count)
+    return count > 0
+}
+
+func listBudgetsHandler(c *gin.Context) {
+    query := db.Order("cost_center ASC, period_start ASC")
+    if costCenter := c.Query("cost_center"); costCenter != "" {
+        query = query.Where("cost_center = ?", costCenter)
+    }
+
+    budgets := []Budget{}
+    if err := query.Find(&budgets).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar orçamentos"})
+        return
+    }
+
+    c.JSON(http.StatusOK, budgets)
+}
+
+func createBudgetHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
+
+    var req BudgetInput
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    budget, err := parseBudgetInput(req)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if budgetOverlaps(budget) {
+        c.JSON(http.StatusConflict, gin.H{"error": "Já existe orçamento para este centro de custo no período"})
+        return
+    }
+
+    budget.CreatedByID = userID.(uint)
+    if err := db.Create(&budget).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar orçamento"})
+        return
+    }
+
+    print_status(fmt.Sprintf("Orçamento de %s criado para %s a %s", budget.CostCenter,
+        budget.PeriodStart.Format("2006-01-02"), budget.PeriodEnd.Format("2006-01-02")))
+    c.JSON(http.StatusCreated, budget)
+}
+
+// getBudgetHandler devolve o orçamento com o consumo atual
+func getBudgetHandler(c *gin.Context) {
+    var budget Budget
+    if err := db.Where("id = ?", c.Param("id")).First(&budget).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Orçamento não encontrado"})
+        return
+    }
+
+    summary, err := summarizeBudget(db, budget)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular consumo do orçamento"})
+        return
+    }
+
+    c.JSON(http.StatusOK, summary)
+}
+
+func updateBudgetHandler(c *gin.Context) {
+    var budget Budget
+    if err := db.Where("id = ?", c.Param("id")).First(&budget).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Orçamento não encontrado"})
+        return
+    }
+
+    var req BudgetInput
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    updated, err := parseBudgetInput(req)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    updated.ID = budget.ID
+    if budgetOverlaps(updated) {
+        c.JSON(http.StatusConflict, gin.H{"error": "Já existe orçamento para este centro de custo no período"})
+        return
+    }
+
+    budget.CostCenter = updated.CostCenter
+    budget.PeriodStart = updated.PeriodStart
+    budget.PeriodEnd = updated.PeriodEnd
+    budget.Amount = updated.Amount
+    if err := db.Save(&budget).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar orçamento"})
+        return
+    }
+
+    c.JSON(http.StatusOK, budget)
+}
+
+func deleteBudgetHandler(c *gin.Context) {
+    result := db.Where("id = ?", c.Param("id")).Delete(&Budget{})
+    if result.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover orçamento"})
+        return
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Orçamento não encontrado"})
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+// budgetSummaryHandler lista o consumo dos orçamentos vigentes na data
+// (?date=, padrão hoje), opcionalmente de um centro de custo
+func budgetSummaryHandler(c *gin.Context) {
+    date := time.Now().Truncate(24 * time.Hour)
+    if value := c.Query("date"); value != "" {
+        parsed, err := time.Parse("2006-01-02", value)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data inválido (use YYYY-MM-DD)"})
+            return
+        }
+        date = parsed
+    }
+
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    query := db.Where("period_start <= ? AND period_end >= ?", date, date).Order("cost_center ASC")
+    if costCenter := c.Query("cost_center"); costCenter != "" {
+        query = query.Where("cost_center = ?", costCenter)
+    }
+    // Gestores acompanham só o centro de custo da própria equipe
+    if !hasRole(user.Role, RoleFinance, RoleDirector) {
+        query = query.Where("cost_center = ?", user.Department)
+    }
+
+    var budgets []Budget
+    if err := query.Find(&budgets).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar orçamentos"})
+        return
+    }
+
+    summaries := make([]BudgetSummary, 0, len(budgets))
+    for _, budget := range budgets {
+        summary, err := summarizeBudget(db, budget)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular consumo do orçamento"})
+            return
+        }
+        summaries = append(summaries, summary)
+    }
+
+    c.JSON(http.StatusOK, summaries)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// setupBudgetScenario coloca o solicitante no centro de custo "Vendas" com
+// orçamento de setembro/2025
+func setupBudgetScenario(t *testing.T, amount int64) (map[string]string, *gin.Engine) {
+    tokens, router := setupApprovalUsers()
+    db.Model(&User{}).Where("email IN ?", []string{"requester@example.com", "manager@example.com"}).Update("department", "Vendas")
+    
+    w := performRequest(router, "POST", "/api/budgets", tokens["finance"], BudgetInput{
+        CostCenter:  "Vendas",
+        PeriodStart: "2025-09-01",
+        PeriodEnd:   "2025-09-30",
+        Amount:      amount,
+    })
+    assert.Equal(t, 201, w.Code)
+    return tokens, router
+}
+
+func createSeptemberTrip(router *gin.Engine, token string, cost int64) TravelRequest {
+    w := performRequest(router, "POST", "/api/travel-requests", token, CreateTravelRequest{
+        Destination:   "Curitiba",
+        DepartureDate: "2025-09-10",
+        ReturnDate:    "2025-09-12",
+        EstimatedCost: cost,
+    })
+    
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    return created
+}
+
+func approveCurrentStep(router *gin.Engine, token string, requestID uint) int {
+    step, _ := currentApprovalStep(requestID)
+    w := performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", requestID, step.ID), token, "*", nil)
+    return w.Code
+}
+
+func TestBudgetCRUDAndSummary(t *testing.T) {
+    tokens, router := setupBudgetScenario(t, 1000000)
+    
+    w := performRequest(router, "POST", "/api/budgets", tokens["requester"], BudgetInput{
+        CostCenter: "Vendas", PeriodStart: "2025-10-01", PeriodEnd: "2025-10-31", Amount: 100,
+    })
+    assert.Equal(t, 403, w.Code)
+    
+    w = performRequest(router, "POST", "/api/budgets", tokens["finance"], BudgetInput{
+        CostCenter: "Vendas", PeriodStart: "2025-09-15", PeriodEnd: "2025-10-15", Amount: 100,
+    })
+    assert.Equal(t, 409, w.Code, "períodos sobrepostos no mesmo centro de custo")
+    
+    w = performRequest(router, "POST", "/api/budgets", tokens["finance"], BudgetInput{
+        CostCenter: "Vendas", PeriodStart: "2025-10-31", PeriodEnd: "2025-10-01", Amount: 100,
+    })
+    assert.Equal(t, 400, w.Code)
+    
+    approved := createSeptemberTrip(router, tokens["requester"], 300000)
+    assert.Equal(t, "Vendas", approved.CostCenter, "padrão: equipe do solicitante")
+    assert.Equal(t, 200, approveCurrentStep(router, tokens["manager"], approved.ID))
+    createSeptemberTrip(router, tokens["requester"], 200000)
+    
+    w = performRequest(router, "GET", "/api/budgets/summary?date=2025-09-15", tokens["manager"], nil)
+    assert.Equal(t, 200, w.Code)
+    var summaries []BudgetSummary
+    json.Unmarshal(w.Body.Bytes(), &summaries)
+    assert.Len(t, summaries, 1)
+    assert.Equal(t, int64(300000), summaries[0].Consumed)
+    assert.Equal(t, int64(200000), summaries[0].Pending)
+    assert.Equal(t, int64(700000), summaries[0].Available)
+    assert.InDelta(t, 0.3, summaries[0].Utilization, 0.0001)
+    
+    w = performRequest(router, "GET", "/api/budgets/summary?date=2025-10-15", tokens["finance"], nil)
+    json.Unmarshal(w.Body.Bytes(), &summaries)
+    assert.Len(t, summaries, 0)
+    
+    path := fmt.Sprintf("/api/budgets/%d", firstBudgetID(t))
+    w = performRequest(router, "PUT", path, tokens["finance"], BudgetInput{
+        CostCenter: "Vendas", PeriodStart: "2025-09-01", PeriodEnd: "2025-09-30", Amount: 250000,
+    })
+    assert.Equal(t, 200, w.Code)
+    
+    w = performRequest(router, "GET", path, tokens["director"], nil)
+    var summary BudgetSummary
+    json.Unmarshal(w.Body.Bytes(), &summary)
+    assert.Equal(t, int64(-50000), summary.Available)
+    
+    w = performRequest(router, "DELETE", path, tokens["finance"], nil)
+    assert.Equal(t, 204, w.Code)
+    w = performRequest(router, "GET", path, tokens["finance"], nil)
+    assert.Equal(t, 404, w.Code)
+}
+
+func firstBudgetID(t *testing.T) uint {
+    var budget Budget
+    assert.NoError(t, db.First(&budget).Error)
+    return budget.ID
+}
+
+func TestBudgetSummaryIsScopedForManagers(t *testing.T) {
+    tokens, router := setupBudgetScenario(t, 1000000)
+    w := performRequest(router, "POST", "/api/budgets", tokens["finance"], BudgetInput{
+        CostCenter: "Marketing", PeriodStart: "2025-09-01", PeriodEnd: "2025-09-30", Amount: 500000,
+    })
+    assert.Equal(t, 201, w.Code)
+    
+    summaryCostCenters := func(token, query string) []string {
+        w := performRequest(router, "GET", "/api/budgets/summary?date=2025-09-15"+query, token, nil)
+        assert.Equal(t, 200, w.Code)
+        var summaries []BudgetSummary
+        json.Unmarshal(w.Body.Bytes(), &summaries)
+        costCenters := []string{}
+        for _, summary := range summaries {
+            costCenters = append(costCenters, summary.CostCenter)
+        }
+        return costCenters
+    }
+    
+    assert.Equal(t, []string{"Marketing", "Vendas"}, summaryCostCenters(tokens["finance"], ""))
+    assert.Equal(t, []string{"Marketing", "Vendas"}, summaryCostCenters(tokens["admin"], ""))
+    assert.Equal(t, []string{"Vendas"}, summaryCostCenters(tokens["manager"], ""))
+    assert.Empty(t, summaryCostCenters(tokens["manager"], "&cost_center=Marketing"))
+    
+    // Gestor sem equipe definida não vê orçamento algum
+    db.Model(&User{}).Where("email = ?", "manager@example.com").Update("department", "")
+    assert.Empty(t, summaryCostCenters(tokens["manager"], ""))
+}
+
+func TestApprovalBlockedWhenBudgetWouldBeExceeded(t *testing.T) {
+    tokens, router := setupBudgetScenario(t, 400000)
+    
+    first := createSeptemberTrip(router, tokens["requester"], 300000)
+    assert.Equal(t, 200, approveCurrentStep(router, tokens["manager"], first.ID))
+    
+    second := createSeptemberTrip(router, tokens["requester"], 200000)
+    step, _ := currentApprovalStep(second.ID)
+    w := performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", second.ID, step.ID), tokens["manager"], "*", nil)
+    assert.Equal(t, 409, w.Code)
+    
+    var body struct {
+        Cost   int64         `json:"cost"`
+        Budget BudgetSummary `json:"budget"`
+    }
+    json.Unmarshal(w.Body.Bytes(), &body)
+    assert.Equal(t, int64(200000), body.Cost)
+    assert.Equal(t, int64(100000), body.Budget.Available)
+    
+    // Nada foi gravado: a etapa continua pendente
+    var request TravelRequest
+    db.First(&request, second.ID)
+    assert.Equal(t, StatusRequested, request.Status)
+    current, ok := currentApprovalStep(second.ID)
+    assert.True(t, ok)
+    assert.Equal(t, step.ID, current.ID)
+    
+    // O solicitante não escapa do orçamento trocando de centro de custo
+    path := fmt.Sprintf("/api/travel-requests/%d", second.ID)
+    w = performMergePatch(router, path, tokens["requester"], "*", `{"cost_center": "Marketing"}`)
+    assert.Equal(t, 403, w.Code)
+    
+    // Outro centro de custo, sem orçamento cadastrado, não é limitado
+    db.Model(&User{}).Where("email = ?", "director@example.com").Update("department", "Marketing")
+    w = performMergePatch(router, path, tokens["admin"], "*", `{"cost_center": "Marketing"}`)
+    assert.Equal(t, 200, w.Code)
+    assert.Equal(t, 200, approveCurrentStep(router, tokens["manager"], second.ID))
+}
+
+func TestCostCenterRestrictedToTravelerTeam(t *testing.T) {
+    tokens, router := setupBudgetScenario(t, 1000000)
+    db.Model(&User{}).Where("email = ?", "director@example.com").Update("department", "Marketing")
+    
+    trip := CreateTravelRequest{Destination: "Curitiba", DepartureDate: "2025-09-10", ReturnDate: "2025-09-12", CostCenter: "Marketing"}
+    w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], trip)
+    assert.Equal(t, 403, w.Code)
+    
+    trip.CostCenter = " Vendas "
+    w = performRequest(router, "POST", "/api/travel-requests", tokens["requester"], trip)
+    assert.Equal(t, 201, w.Code)
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Equal(t, "Vendas", created.CostCenter)
+    
+    // Financeiro aloca em qualquer centro de custo conhecido
+    trip.CostCenter = "Inexistente"
+    w = performRequest(router, "POST", "/api/travel-requests", tokens["finance"], trip)
+    assert.Equal(t, 400, w.Code)
+    assert.Contains(t, w.Body.String(), "Centro de custo desconhecido")
+    
+    trip.CostCenter = "Marketing"
+    w = performRequest(router, "POST", "/api/travel-requests", tokens["finance"], trip)
+    assert.Equal(t, 201, w.Code)
+}
+
+func TestApprovalEscalatesWhenBudgetWouldBeExceeded(t *testing.T) {
+    previous := budgetEnforcement
+    budgetEnforcement = BudgetEscalate
+    defer func() { budgetEnforcement = previous }()
+    
+    tokens, router := setupBudgetScenario(t, 100000)
+    
+    request := createSeptemberTrip(router, tokens["requester"], 200000)
+    assert.Equal(t, 200, approveCurrentStep(router, tokens["manager"], request.ID))
+    
+    db.First(&request, request.ID)
+    assert.Equal(t, StatusRequested, request.Status)
+    step, ok := currentApprovalStep(request.ID)
+    assert.True(t, ok)
+    assert.Equal(t, budgetEscalationStep, step.Name)
+    assert.Equal(t, RoleDirector, step.ApproverRole)
+    
+    assert.Equal(t, 403, approveCurrentStep(router, tokens["manager"], request.ID))
+    assert.Equal(t, 200, approveCurrentStep(router, tokens["director"], request.ID))
+    
+    db.First(&request, request.ID)
+    assert.Equal(t, StatusApproved, request.Status)
+}
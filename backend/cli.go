@@ -0,0 +1,524 @@
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "strings"
+    "time"
+
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+)
+
+// Saída dos subcomandos, substituída nos testes
+var cliOutput io.Writer = os.Stdout
+
+const cliUsage = `Uso: travel-requests <comando> [opções]
+
+Comandos:
+  serve                                  inicia o servidor HTTP (padrão)
+  migrate up | down [n] | status         gerencia as migrations do banco
+  user create --name --email --password [--role] [--department] [--manager]
+  user reset-password --email [--password]
+  request reassign --id --to --actor [--reason]
+  request normalize-destinations         associa pedidos antigos ao catálogo de destinos
+  seed --demo                            cria usuários e pedidos de demonstração`
+
+// Senha dos usuários criados por "seed --demo"
+const demoPassword = "demo123"
+
+// runCLI despacha os subcomandos do binário. Sem argumentos, inicia o servidor.
+func runCLI(args []string) error {
+    if len(args) == 0 || args[0] == "serve" {
+        serve()
+        return nil
+    }
+
+    // Só os comandos que criam ou alteram pedidos dependem das políticas;
+    // migrate e user funcionam mesmo com a configuração incompleta
+    var run func([]string) error
+    withConfig := false
+    switch args[0] {
+    case "migrate":
+        run = runMigrateCommand
+    case "user":
+        run = runUserCommand
+    case "request":
+        run, withConfig = runRequestCommand, true
+    case "seed":
+        run, withConfig = runSeedCommand, true
+    case "help", "-h", "--help":
+        fmt.Fprintln(cliOutput, cliUsage)
+        return nil
+    default:
+        return fmt.Errorf("comando desconhecido: %s\n\n%s", args[0], cliUsage)
+    }
+
+    connectDatabase()
+    if withConfig {
+        loadConfig()
+    }
+    return run(args[1:])
+}
+
+func serve() {
+    print_status("Iniciando Travel Requests Backend...")
+
+    setupDatabase()
+    bootstrapAdmin()
+    loadJWTKeys()
+    loadConfig()
+    loadNotifiers()
+    startOutboxDispatcher()
+    startEventHub()
+    setupRoutes()
+}
+
+// loadConfig carrega as políticas e regras de negócio do ambiente. Servidor e
+// comandos que mexem em pedidos passam por ela para aplicar as mesmas regras da API.
+func loadConfig() {
+    loadApprovalPolicy()
+    loadTravelPolicy()
+    loadExchangeRates()
+    loadBudgetConfig()
+    loadDestinationConfig()
+    loadOverlapConfig()
+}
+
+// newFlagSet cria um FlagSet que devolve erros em vez de encerrar o processo
+func newFlagSet(name string) *flag.FlagSet {
+    fs := flag.NewFlagSet(name, flag.ContinueOnError)
+    fs.SetOutput(cliOutput)
+    return fs
+}
+
+func runUserCommand(args []string) error {
+    if len(args) == 0 {
+        return errors.New("uso: user create | user reset-password")
+    }
+
+    switch args[0] {
+    case "create":
+        return userCreateCommand(args[1:])
+    case "reset-password":
+        return userResetPasswordCommand(args[1:])
+    default:
+        return fmt.Errorf("subcomando de user desconhecido: %s", args[0])
+    }
+}
+
+func userCreateCommand(args []string) error {
+    fs := newFlagSet("user create")
+    name := fs.String("name", "", "nome do usuário")
+    email := fs.String("email", "", "email do usuário")
+    password := fs.String("password", "", "senha (mínimo 6 caracteres)")
+    role := fs.String("role", RoleRequester, "papel do usuário")
+    department := fs.String("department", "", "departamento do usuário")
+    manager := fs.String("manager", "", "email do gestor direto")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    if *name == "" || *email == "" {
+        return errors.New("--name e --email são obrigatórios")
+    }
+    if len(*password) < 6 {
+        return errors.New("--password deve ter pelo menos 6 caracteres")
+    }
+    if !validRoles[*role] {
+        return fmt.Errorf("papel inválido: %s", *role)
+    }
+
+    var existing User
+    if err := db.Where("email = ?", *email).First(&existing).Error; err == nil {
+        return fmt.Errorf("email já está em uso: %s", *email)
+    }
+
+    user, err := createUser(*name, *email, *password, *role, *department)
+    if err != nil {
+        return err
+    }
+
+    if *manager != "" {
+        managerUser, err := findUserByEmail(*manager)
+        if err != nil {
+            return err
+        }
+        if err := db.Model(&user).Update("manager_id", managerUser.ID).Error; err != nil {
+            return err
+        }
+    }
+
+    fmt.Fprintf(cliOutput, "Usuário %d criado: %s <%s> (%s)\n", user.ID, user.Name, user.Email, user.Role)
+    return nil
+}
+
+func userResetPasswordCommand(args []string) error {
+    fs := newFlagSet("user reset-password")
+    email := fs.String("email", "", "email do usuário")
+    password := fs.String("password", "", "nova senha; gerada aleatoriamente se omitida")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    if *email == "" {
+        return errors.New("--email é obrigatório")
+    }
+
+    user, err := findUserByEmail(*email)
+    if err != nil {
+        return err
+    }
+
+    newPassword := *password
+    generated := newPassword == ""
+    if generated {
+        if newPassword, err = randomToken(12); err != nil {
+            return err
+        }
+    } else if len(newPassword) < 6 {
+        return errors.New("--password deve ter pelo menos 6 caracteres")
+    }
+
+    hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+    if err != nil {
+        return err
+    }
+
+    // Sessões abertas com a senha antiga são encerradas junto com a troca
+    err = db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&user).Update("password", string(hashed)).Error; err != nil {
+            return err
+        }
+        return tx.Model(&RefreshToken{}).
+            Where("user_id = ? AND revoked_at IS NULL", user.ID).
+            Update("revoked_at", time.Now()).Error
+    })
+    if err != nil {
+        return err
+    }
+
+    fmt.Fprintf(cliOutput, "Senha de %s redefinida; sessões ativas foram encerradas\n", user.Email)
+    if generated {
+        fmt.Fprintf(cliOutput, "Nova senha: %s\n", newPassword)
+    }
+    return nil
+}
+
+func runRequestCommand(args []string) error {
+    if len(args) == 0 {
+        return errors.New("uso: request reassign --id <id> --to <email> --actor <email> | request normalize-destinations")
+    }
+
+    switch args[0] {
+    case "reassign":
+        return requestReassignCommand(args[1:])
+    case "normalize-destinations":
+        return requestNormalizeDestinationsCommand()
+    default:
+        return fmt.Errorf("subcomando de request desconhecido: %s", args[0])
+    }
+}
+
+func requestReassignCommand(args []string) error {
+    fs := newFlagSet("request reassign")
+    id := fs.Uint("id", 0, "id do pedido")
+    to := fs.String("to", "", "email do novo responsável pelo pedido")
+    actor := fs.String("actor", "", "email de quem executa a operação, registrado no histórico")
+    reason := fs.String("reason", "", "motivo da reatribuição")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    if *id == 0 || *to == "" || *actor == "" {
+        return errors.New("--id, --to e --actor são obrigatórios")
+    }
+
+    request, err := reassignTravelRequest(*id, *to, *actor, *reason)
+    if err != nil {
+        return err
+    }
+
+    fmt.Fprintf(cliOutput, "Pedido %d reatribuído para %s\n", request.ID, *to)
+    return nil
+}
+
+// reassignTravelRequest transfere o pedido para outro usuário. Se o antigo
+// responsável era também o viajante, o novo passa a ser o viajante. A troca
+// fica no histórico e, se o pedido aguarda aprovação, a cadeia é remontada
+// para o gestor do viajante.
+func reassignTravelRequest(id uint, toEmail, actorEmail, reason string) (TravelRequest, error) {
+    var request TravelRequest
+    if err := withTravelRequestDetails(db).First(&request, id).Error; err != nil {
+        return request, fmt.Errorf("pedido %d não encontrado", id)
+    }
+
+    newOwner, err := findUserByEmail(toEmail)
+    if err != nil {
+        return request, err
+    }
+    actor, err := findUserByEmail(actorEmail)
+    if err != nil {
+        return request, err
+    }
+
+    if request.UserID == newOwner.ID {
+        return request, fmt.Errorf("pedido %d já pertence a %s", id, newOwner.Email)
+    }
+
+    if reason == "" {
+        reason = fmt.Sprintf("Pedido reatribuído para %s", newOwner.Email)
+    }
+
+    travelerChanged := request.TravelerID == request.UserID
+    if travelerChanged {
+        request.TravelerID = newOwner.ID
+        request.RequesterName = newOwner.Name
+    }
+    request.UserID = newOwner.ID
+
+    // Novo viajante: alertas e sobreposições são reavaliados como na edição
+    if travelerChanged {
+        if err := applyTravelPolicy(&request); err != nil {
+            return request, err
+        }
+    }
+
+    err = db.Transaction(func(tx *gorm.DB) error {
+        if travelerChanged {
+            if err := checkTripOverlaps(tx, &request); err != nil {
+                return err
+            }
+        }
+        if err := saveTravelRequest(tx, &request); err != nil {
+            return err
+        }
+        if err := recordStatusChange(tx, request, request.Status, actor.ID, reason); err != nil {
+            return err
+        }
+        err := enqueueEvent(tx, TravelRequestEvent{
+            Type:    EventRequestReassigned,
+            Request: request,
+            ActorID: actor.ID,
+            Reason:  reason,
+        })
+        if err != nil {
+            return err
+        }
+        if request.Status == StatusRequested {
+            return resetApprovalChain(tx, request, actor.ID)
+        }
+        return nil
+    })
+    return request, err
+}
+
+func requestNormalizeDestinationsCommand() error {
+    requests, legs, err := normalizeStoredDestinations()
+    if err != nil {
+        return err
+    }
+
+    fmt.Fprintf(cliOutput, "Destinos normalizados: %d pedidos, %d trechos\n", requests, legs)
+    return nil
+}
+
+// normalizeStoredDestinations associa ao catálogo os pedidos e trechos gravados
+// antes dele (ou com destinos adicionados ao catálogo depois). Só nome e ID
+// mudam: versão, datas e a classificação internacional ficam como estão.
+func normalizeStoredDestinations() (int, int, error) {
+    var requestCount, legCount int
+    err := db.Transaction(func(tx *gorm.DB) error {
+        var requests []TravelRequest
+        if err := tx.Where("destination_id = '' OR destination_id IS NULL").Find(&requests).Error; err != nil {
+            return err
+        }
+        for _, request := range requests {
+            name, id := canonicalPlace(request.Destination)
+            if id == "" {
+                continue
+            }
+            err := tx.Model(&TravelRequest{}).Where("id = ?", request.ID).
+                UpdateColumns(map[string]interface{}{"destination": name, "destination_id": id}).Error
+            if err != nil {
+                return err
+            }
+            requestCount++
+        }
+
+        var legs []ItineraryLeg
+        if err := tx.Where("origin_id = '' OR origin_id IS NULL OR destination_id = '' OR destination_id IS NULL").Find(&legs).Error; err != nil {
+            return err
+        }
+        for _, leg := range legs {
+            origin, originID := canonicalPlace(leg.Origin)
+            destination, destinationID := canonicalPlace(leg.Destination)
+            if originID == leg.OriginID && destinationID == leg.DestinationID {
+                continue
+            }
+            err := tx.Model(&ItineraryLeg{}).Where("id = ?", leg.ID).UpdateColumns(map[string]interface{}{
+                "origin": origin, "origin_id": originID, "destination": destination, "destination_id": destinationID,
+            }).Error
+            if err != nil {
+                return err
+            }
+            legCount++
+        }
+        return nil
+    })
+    return requestCount, legCount, err
+}
+
+func runSeedCommand(args []string) error {
+    fs := newFlagSet("seed")
+    demo := fs.Bool("demo", false, "cria usuários e pedidos de demonstração")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    if !*demo {
+        return errors.New("uso: seed --demo")
+    }
+
+    return seedDemoData()
+}
+
+// seedDemoData cria uma equipe de demonstração com pedidos em vários status.
+// Pode ser executado mais de uma vez: usuários existentes são reaproveitados.
+func seedDemoData() error {
+    team := []struct {
+        Name, Email, Role, Department string
+    }{
+        {"Admin Demo", "admin@demo.local", RoleAdmin, "TI"},
+        {"Gestora Demo", "gestora@demo.local", RoleManager, "Comercial"},
+        {"Solicitante Demo", "solicitante@demo.local", RoleRequester, "Comercial"},
+        {"Financeiro Demo", "financeiro@demo.local", RoleFinance, "Financeiro"},
+        {"Diretoria Demo", "diretoria@demo.local", RoleDirector, "Diretoria"},
+    }
+
+    users := map[string]User{}
+    emails := make([]string, 0, len(team))
+    for _, member := range team {
+        emails = append(emails, member.Email)
+        user, err := findUserByEmail(member.Email)
+        if err != nil {
+            if user, err = createUser(member.Name, member.Email, demoPassword, member.Role, member.Department); err != nil {
+                return err
+            }
+        }
+        users[member.Role] = user
+    }
+
+    requester := users[RoleRequester]
+    if err := db.Model(&requester).Update("manager_id", users[RoleManager].ID).Error; err != nil {
+        return err
+    }
+
+    var existing int64
+    db.Model(&TravelRequest{}).Where("user_id = ?", requester.ID).Count(&existing)
+    if existing > 0 {
+        fmt.Fprintln(cliOutput, "Dados de demonstração já existentes; nenhum pedido criado")
+        return nil
+    }
+
+    today := time.Now().Truncate(24 * time.Hour)
+    samples := []struct {
+        Destination   string
+        Status        string
+        StartInDays   int
+        Days          int
+        EstimatedCost int64
+        International bool
+    }{
+        {"São Paulo", StatusDraft, 30, 2, 120000, false},
+        {"Rio de Janeiro", StatusRequested, 14, 3, 250000, false},
+        {"Lisboa", StatusRequested, 45, 7, 1200000, true},
+        {"Brasília", StatusApproved, 7, 1, 80000, false},
+    }
+
+    return db.Transaction(func(tx *gorm.DB) error {
+        for _, sample := range samples {
+            departure := today.AddDate(0, 0, sample.StartInDays)
+            destination, destinationID := canonicalPlace(sample.Destination)
+            request := TravelRequest{
+                RequesterName: requester.Name,
+                Destination:   destination,
+                DestinationID: destinationID,
+                DepartureDate: departure,
+                ReturnDate:    departure.AddDate(0, 0, sample.Days),
+                Status:        sample.Status,
+                EstimatedCost: sample.EstimatedCost,
+                International: sample.International,
+                CostCenter:    requester.Department,
+                UserID:        requester.ID,
+                TravelerID:    requester.ID,
+                CreatedByID:   requester.ID,
+            }
+            if err := tx.Create(&request).Error; err != nil {
+                return err
+            }
+            if err := recordStatusChange(tx, request, "", requester.ID, ""); err != nil {
+                return err
+            }
+            if err := syncApprovalChain(tx, request, requester.ID); err != nil {
+                return err
+            }
+        }
+
+        fmt.Fprintf(cliOutput, "Dados de demonstração criados: %d usuários (senha %q) e %d pedidos\n",
+            len(team), demoPassword, len(samples))
+        fmt.Fprintln(cliOutput, "Emails: "+strings.Join(emails, ", "))
+        return nil
+    })
+}
+
+func createUser(name, email, password, role, department string) (User, error) {
+    hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return User{}, err
+    }
+
+    user := User{
+        Name:       name,
+        Email:      email,
+        Password:   string(hashed),
+        Role:       role,
+        Department: department,
+        Locale:     LocalePortuguese,
+    }
+    if err := db.Create(&user).Error; err != nil {
+        return User{}, err
+    }
+    return user, nil
+}
+
+// bootstrapAdmin cria o administrador inicial a partir de ADMIN_EMAIL e
+// ADMIN_PASSWORD, se definidos e o email ainda não estiver cadastrado. O
+// cadastro público nunca concede o papel admin.
+func bootstrapAdmin() {
+    email := getEnv("ADMIN_EMAIL", "")
+    password := getEnv("ADMIN_PASSWORD", "")
+    if email == "" || password == "" {
+        return
+    }
+
+    if _, err := findUserByEmail(email); err == nil {
+        return
+    }
+    if _, err := createUser(getEnv("ADMIN_NAME", "Administrador"), email, password, RoleAdmin, ""); err != nil {
+        log.Fatal("Falha ao criar administrador inicial:", err)
+    }
+    print_status(fmt.Sprintf("Administrador inicial criado: %s", email))
+}
+
+func findUserByEmail(email string) (User, error) {
+    var user User
+    if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+        return user, fmt.Errorf("usuário não encontrado: %s", email)
+    }
+    return user, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func captureCLIOutput(t *testing.T) *bytes.Buffer {
+    buffer := &bytes.Buffer{}
+    previous := cliOutput
+    cliOutput = buffer
+    t.Cleanup(func() { cliOutput = previous })
+    return buffer
+}
+
+func TestCLIUserCreateAndLogin(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    output := captureCLIOutput(t)
+    
+    err := runUserCommand([]string{"create", "--name", "Ops Admin", "--email", "ops@example.com",
+        "--password", "password123", "--role", RoleAdmin})
+    assert.NoError(t, err)
+    assert.Contains(t, output.String(), "ops@example.com")
+    
+    var user User
+    db.Where("email = ?", "ops@example.com").First(&user)
+    assert.Equal(t, RoleAdmin, user.Role)
+    
+    access, _ := loginTokens(router, "ops@example.com")
+    w := performRequest(router, "GET", "/api/users", access, nil)
+    assert.Equal(t, 200, w.Code)
+    
+    err = runUserCommand([]string{"create", "--name", "Dup", "--email", "ops@example.com", "--password", "password123"})
+    assert.Error(t, err)
+    
+    err = runUserCommand([]string{"create", "--name", "X", "--email", "x@example.com", "--password", "password123", "--role", "root"})
+    assert.Error(t, err)
+}
+
+func TestCLIResetPasswordRevokesSessions(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    captureCLIOutput(t)
+    
+    registerAndLogin(router, "Test User", "test@example.com", "")
+    access, _ := loginTokens(router, "test@example.com")
+    
+    err := runUserCommand([]string{"reset-password", "--email", "test@example.com", "--password", "newsecret"})
+    assert.NoError(t, err)
+    
+    w := performRequest(router, "GET", "/api/travel-requests", access, nil)
+    assert.Equal(t, 401, w.Code)
+    
+    w = performRequest(router, "POST", "/api/auth/login", "", LoginRequest{Email: "test@example.com", Password: "newsecret"})
+    assert.Equal(t, 200, w.Code)
+}
+
+func TestCLIReassignRequestRebuildsApprovalChain(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    captureCLIOutput(t)
+    
+    registerAndLogin(router, "Other", "other@example.com", "")
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    
+    step, ok := currentApprovalStep(requestID)
+    assert.True(t, ok)
+    assert.Equal(t, uint(2), *step.ApproverID)
+    
+    err := runRequestCommand([]string{"reassign", "--id", "1", "--to", "other@example.com", "--actor", "admin@example.com"})
+    assert.NoError(t, err)
+    
+    var request TravelRequest
+    db.First(&request, requestID)
+    var other User
+    db.Where("email = ?", "other@example.com").First(&other)
+    assert.Equal(t, other.ID, request.UserID)
+    assert.Equal(t, 2, request.Version)
+    
+    // O novo responsável não tem gestor: a etapa cai para qualquer aprovador
+    step, ok = currentApprovalStep(requestID)
+    assert.True(t, ok)
+    assert.Nil(t, step.ApproverID)
+    assert.Equal(t, RoleApprover, step.ApproverRole)
+    
+    var history []StatusHistory
+    db.Where("travel_request_id = ?", requestID).Order("id").Find(&history)
+    assert.Len(t, history, 2)
+    assert.Equal(t, "Pedido reatribuído para other@example.com", history[1].Reason)
+    
+    var events int64
+    db.Model(&OutboxEvent{}).Where("travel_request_id = ? AND event_type = ?", requestID, EventRequestReassigned).Count(&events)
+    assert.Equal(t, int64(1), events)
+    
+    err = runRequestCommand([]string{"reassign", "--id", "1", "--to", "other@example.com", "--actor", "admin@example.com"})
+    assert.Error(t, err)
+}
+
+func TestCLIReassignChecksTravelerOverlaps(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    captureCLIOutput(t)
+    withOverlapMode(t, OverlapBlock)
+    
+    other := registerAndLogin(router, "Other", "other@example.com", "")
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    createTestTravelRequest(router, other, "Natal")
+    
+    // O novo responsável passaria a viajar em dois lugares ao mesmo tempo
+    err := runRequestCommand([]string{"reassign", "--id", fmt.Sprint(requestID), "--to", "other@example.com", "--actor", "admin@example.com"})
+    var overlap *tripOverlapError
+    assert.ErrorAs(t, err, &overlap)
+    
+    var request TravelRequest
+    db.First(&request, requestID)
+    assert.Equal(t, uint(3), request.UserID)
+    assert.Equal(t, 1, request.Version)
+    
+    // No modo warn a reatribuição segue e o conflito vira alerta
+    overlapMode = OverlapWarn
+    err = runRequestCommand([]string{"reassign", "--id", fmt.Sprint(requestID), "--to", "other@example.com", "--actor", "admin@example.com"})
+    assert.NoError(t, err)
+    db.First(&request, requestID)
+    assert.Len(t, request.PolicyWarnings, 1)
+    assert.Equal(t, overlapRule, request.PolicyWarnings[0].Rule)
+}
+
+func TestCLIReassignKeepsLegsAndCostItems(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    captureCLIOutput(t)
+    
+    registerAndLogin(router, "Other", "other@example.com", "")
+    w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], costlyTrip(
+        CostItemInput{Category: CostAirfare, Description: "GRU-LIS", Amount: 300000},
+        CostItemInput{Category: CostLodging, Amount: 80000},
+    ))
+    assert.Equal(t, 201, w.Code)
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    
+    request, err := reassignTravelRequest(created.ID, "other@example.com", "admin@example.com", "")
+    assert.NoError(t, err)
+    assert.Len(t, request.CostItems, 2)
+    assert.Len(t, request.Legs, len(created.Legs))
+    assert.Equal(t, created.EstimatedCost, request.EstimatedCost)
+    
+    // O evento leva o pedido completo, como os emitidos pela API
+    var entry OutboxEvent
+    db.Where("travel_request_id = ? AND event_type = ?", created.ID, EventRequestReassigned).First(&entry)
+    assert.Contains(t, entry.Payload, "GRU-LIS")
+}
+
+func TestCLISeedDemoIsIdempotent(t *testing.T) {
+    setupTestDB()
+    captureCLIOutput(t)
+    
+    assert.NoError(t, runSeedCommand([]string{"--demo"}))
+    assert.NoError(t, runSeedCommand([]string{"--demo"}))
+    
+    var users, requests int64
+    db.Model(&User{}).Count(&users)
+    db.Model(&TravelRequest{}).Count(&requests)
+    assert.Equal(t, int64(5), users)
+    assert.Equal(t, int64(4), requests)
+    
+    var requester User
+    db.Where("email = ?", "solicitante@demo.local").First(&requester)
+    assert.NotNil(t, requester.ManagerID)
+    
+    assert.Error(t, runSeedCommand(nil))
+}
+
+func TestCLIUnknownCommand(t *testing.T) {
+    assert.Error(t, runCLI([]string{"bogus"}))
+}
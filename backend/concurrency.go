@@ -0,0 +1,84 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// Retornado quando o pedido foi alterado por outra requisição desde a leitura
+var errVersionConflict = errors.New("conflito de versão do pedido de viagem")
+
+// saveTravelRequest grava o pedido com UPDATE condicional à versão lida,
+// incrementando-a. Substitui o db.Save, que sobrescrevia alterações concorrentes.
+func saveTravelRequest(tx *gorm.DB, request *TravelRequest) error {
+    expected := request.Version
+    request.Version = expected + 1
+
+    result := tx.Model(request).Where("version = ?", expected).Select("*").Omit("id", "created_at", clause.Associations).Updates(request)
+    if result.Error != nil {
+        request.Version = expected
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        request.Version = expected
+        return errVersionConflict
+    }
+    return nil
+}
+
+// respondSaveError traduz o erro de gravação: 412 em conflito de versão, 500 nos demais
+func respondSaveError(c *gin.Context, err error, message string) {
+    if errors.Is(err, errVersionConflict) {
+        c.JSON(http.StatusPreconditionFailed, gin.H{"error": "O pedido foi alterado por outra requisição. Recarregue e tente novamente."})
+        return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+func travelRequestETag(request TravelRequest) string {
+    return fmt.Sprintf("\"%d-%d\"", request.ID, request.Version)
+}
+
+func setETag(c *gin.Context, request TravelRequest) {
+    c.Header("ETag", travelRequestETag(request))
+}
+
+// etagMatches verifica se algum ETag da lista do header corresponde ao pedido
+func etagMatches(header string, request TravelRequest) bool {
+    current := travelRequestETag(request)
+    for _, tag := range strings.Split(header, ",") {
+        tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+        if tag == "*" || tag == current {
+            return true
+        }
+    }
+    return false
+}
+
+// checkIfMatch aplica a pré-condição If-Match das mutações do pedido (PUT,
+// PATCH, DELETE, aprovação e rejeição): 428 sem o header, 412 se a versão
+// informada não é a atual. "*" aceita qualquer versão.
+func checkIfMatch(c *gin.Context, request TravelRequest) bool {
+    header := c.GetHeader("If-Match")
+    if header == "" {
+        setETag(c, request)
+        c.JSON(http.StatusPreconditionRequired, gin.H{"error": "Header If-Match é obrigatório para alterar o pedido"})
+        return false
+    }
+    if etagMatches(header, request) {
+        return true
+    }
+
+    setETag(c, request)
+    c.JSON(http.StatusPreconditionFailed, gin.H{
+        "error":           "If-Match não corresponde à versão atual do pedido",
+        "current_version": request.Version,
+    })
+    return false
+}
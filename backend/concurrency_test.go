@@ -0,0 +1,136 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestETagAndConditionalRequests(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    adminToken := registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    
+    id := createTestTravelRequest(router, ownerToken, "Aracaju")
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    
+    w := performRequest(router, "GET", path, ownerToken, nil)
+    etag := w.Header().Get("ETag")
+    assert.Equal(t, fmt.Sprintf("\"%d-1\"", id), etag)
+    
+    // If-None-Match com a versão atual
+    w = httptest.NewRecorder()
+    req, _ := http.NewRequest("GET", path, nil)
+    req.Header.Set("Authorization", "Bearer "+ownerToken)
+    req.Header.Set("If-None-Match", etag)
+    router.ServeHTTP(w, req)
+    assert.Equal(t, 304, w.Code)
+    
+    // Aprovação com If-Match correto incrementa a versão
+    w = performConditional(router, "PUT", path+"/status", adminToken, etag, UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 200, w.Code)
+    assert.Equal(t, fmt.Sprintf("\"%d-2\"", id), w.Header().Get("ETag"))
+    
+    // Cancelamento baseado na versão antiga é recusado
+    w = performConditional(router, "DELETE", path, ownerToken, etag, nil)
+    assert.Equal(t, 412, w.Code)
+    
+    var request TravelRequest
+    db.First(&request, id)
+    assert.Equal(t, StatusApproved, request.Status)
+}
+
+func TestConcurrentSaveIsDetected(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    id := createTestTravelRequest(router, ownerToken, "Aracaju")
+    
+    var first, second TravelRequest
+    db.First(&first, id)
+    db.First(&second, id)
+    
+    first.Status = StatusApproved
+    assert.NoError(t, saveTravelRequest(db, &first))
+    assert.Equal(t, 2, first.Version)
+    
+    second.Status = StatusCancelled
+    assert.ErrorIs(t, saveTravelRequest(db, &second), errVersionConflict)
+    
+    var stored TravelRequest
+    db.First(&stored, id)
+    assert.Equal(t, StatusApproved, stored.Status)
+}
+
+func TestIfMatchRequiredOnAllMutations(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    id := createTestTravelRequest(router, tokens["requester"], "Aracaju")
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    update := UpdateTravelRequest{Destination: "Maceió", DepartureDate: "2025-08-15", ReturnDate: "2025-08-20"}
+    step, _ := currentApprovalStep(id)
+    
+    w := performRequest(router, "PUT", path, tokens["requester"], update)
+    assert.Equal(t, 428, w.Code)
+    assert.Equal(t, fmt.Sprintf("\"%d-1\"", id), w.Header().Get("ETag"))
+    
+    w = performMergePatch(router, path, tokens["requester"], "", `{"destination": "Maceió"}`)
+    assert.Equal(t, 428, w.Code)
+    
+    w = performRequest(router, "PUT", path+"/status", tokens["admin"], UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 428, w.Code)
+    
+    w = performRequest(router, "POST", fmt.Sprintf("%s/approvals/%d/approve", path, step.ID), tokens["manager"], nil)
+    assert.Equal(t, 428, w.Code)
+    
+    w = performRequest(router, "POST", fmt.Sprintf("%s/approvals/%d/reject", path, step.ID), tokens["manager"], nil)
+    assert.Equal(t, 428, w.Code)
+    
+    w = performRequest(router, "DELETE", path, tokens["requester"], nil)
+    assert.Equal(t, 428, w.Code)
+    
+    var request TravelRequest
+    db.First(&request, id)
+    assert.Equal(t, "Aracaju", request.Destination)
+    assert.Equal(t, StatusRequested, request.Status)
+    assert.Equal(t, 1, request.Version)
+    
+    w = performConditional(router, "DELETE", path, tokens["requester"], w.Header().Get("ETag"), nil)
+    assert.Equal(t, 200, w.Code)
+}
+
+func TestStaleIfMatchIsRejectedOnAllMutations(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    id := createTestTravelRequest(router, tokens["requester"], "Aracaju")
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    stale := fmt.Sprintf("\"%d-1\"", id)
+    
+    w := performMergePatch(router, path, tokens["requester"], stale, `{"destination": "Maceió"}`)
+    assert.Equal(t, 200, w.Code)
+    current := w.Header().Get("ETag")
+    assert.Equal(t, fmt.Sprintf("\"%d-2\"", id), current)
+    step, _ := currentApprovalStep(id)
+    
+    w = performConditional(router, "DELETE", path, tokens["requester"], stale, nil)
+    assert.Equal(t, 412, w.Code)
+    assert.Equal(t, current, w.Header().Get("ETag"))
+    assert.Contains(t, w.Body.String(), `"current_version":2`)
+    
+    w = performConditional(router, "POST", fmt.Sprintf("%s/approvals/%d/approve", path, step.ID), tokens["manager"], stale, nil)
+    assert.Equal(t, 412, w.Code)
+    
+    w = performConditional(router, "POST", fmt.Sprintf("%s/approvals/%d/reject", path, step.ID), tokens["manager"], stale, nil)
+    assert.Equal(t, 412, w.Code)
+    
+    var request TravelRequest
+    db.First(&request, id)
+    assert.Equal(t, StatusRequested, request.Status)
+    assert.Equal(t, 2, request.Version)
+}
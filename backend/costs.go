@@ -0,0 +1,131 @@
+package main
+
+import (
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+
+    "gorm.io/gorm"
+)
+
+// Categorias de custo de uma viagem
+const (
+    CostAirfare         = "airfare"
+    CostLodging         = "lodging"
+    CostGroundTransport = "ground_transport"
+    CostMeals           = "meals"
+)
+
+// Moeda do custo total (EstimatedCost), usada na cadeia de aprovação e nos relatórios
+const BaseCurrency = "BRL"
+
+// Cotação de cada moeda aceita em reais. Sobrescrita por EXCHANGE_RATES.
+var exchangeRates = map[string]float64{
+    "BRL": 1,
+    "USD": 5.0,
+    "EUR": 5.5,
+}
+
+// Item de custo estimado do pedido. O valor é guardado na moeda original e
+// convertido para reais com a cotação do momento do cadastro.
+type CostItem struct {
+    ID              uint    `json:"id" gorm:"primaryKey"`
+    TravelRequestID uint    `json:"-" gorm:"index"`
+    Category        string  `json:"category"`
+    Description     string  `json:"description,omitempty"`
+    Amount          int64   `json:"amount"`        // Centavos na moeda do item
+    Currency        string  `json:"currency"`      // ISO 4217
+    ExchangeRate    float64 `json:"exchange_rate"` // Reais por unidade da moeda
+    BaseAmount      int64   `json:"base_amount"`   // Centavos em reais
+}
+
+type CostItemInput struct {
+    Category    string `json:"category" binding:"required,oneof=airfare lodging ground_transport meals"`
+    Description string `json:"description"`
+    Amount      int64  `json:"amount" binding:"gt=0"` // Centavos
+    Currency    string `json:"currency"`              // Padrão: BRL
+}
+
+// loadExchangeRates lê cotações no formato "USD=5.10,EUR=5.60"
+func loadExchangeRates() {
+    value := getEnv("EXCHANGE_RATES", "")
+    if value == "" {
+        return
+    }
+    for _, pair := range strings.Split(value, ",") {
+        code, rate, ok := strings.Cut(strings.TrimSpace(pair), "=")
+        parsed, err := strconv.ParseFloat(rate, 64)
+        if !ok || err != nil || parsed <= 0 {
+            print_status(fmt.Sprintf("Cotação ignorada em EXCHANGE_RATES: %q", pair))
+            continue
+        }
+        exchangeRates[strings.ToUpper(code)] = parsed
+    }
+}
+
+// buildCostItems valida os itens e calcula o total em reais. Sem itens, vale
+// o estimated_cost informado diretamente; com itens, ele é calculado e, se
+// informado, precisa conferir com a soma.
+func buildCostItems(inputs []CostItemInput, estimatedCost int64) ([]CostItem, int64, error) {
+    if len(inputs) == 0 {
+        return nil, estimatedCost, nil
+    }
+
+    items := make([]CostItem, 0, len(inputs))
+    var total int64
+    for _, input := range inputs {
+        currency := strings.ToUpper(input.Currency)
+        if currency == "" {
+            currency = BaseCurrency
+        }
+        rate, ok := exchangeRates[currency]
+        if !ok {
+            return nil, 0, fmt.Errorf("Moeda não suportada: %s", input.Currency)
+        }
+
+        item := CostItem{
+            Category:     input.Category,
+            Description:  input.Description,
+            Amount:       input.Amount,
+            Currency:     currency,
+            ExchangeRate: rate,
+            BaseAmount:   int64(math.Round(float64(input.Amount) * rate)),
+        }
+        total += item.BaseAmount
+        items = append(items, item)
+    }
+
+    if estimatedCost != 0 && estimatedCost != total {
+        return nil, 0, fmt.Errorf("estimated_cost (%d) não confere com a soma dos itens de custo (%d)", estimatedCost, total)
+    }
+    return items, total, nil
+}
+
+// replaceCostItems substitui os itens gravados pelos do pedido
+func replaceCostItems(tx *gorm.DB, request *TravelRequest) error {
+    if err := tx.Where("travel_request_id = ?", request.ID).Delete(&CostItem{}).Error; err != nil {
+        return err
+    }
+    if len(request.CostItems) == 0 {
+        return nil
+    }
+    for i := range request.CostItems {
+        request.CostItems[i].TravelRequestID = request.ID
+    }
+    return tx.Create(&request.CostItems).Error
+}
+
+// costItemInputs devolve os itens no formato de entrada, para a edição
+func costItemInputs(items []CostItem) []CostItemInput {
+    inputs := make([]CostItemInput, 0, len(items))
+    for _, item := range items {
+        inputs = append(inputs, CostItemInput{
+            Category:    item.Category,
+            Description: item.Description,
+            Amount:      item.Amount,
+            Currency:    item.Currency,
+        })
+    }
+    return inputs
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func costlyTrip(items ...CostItemInput) CreateTravelRequest {
+    return CreateTravelRequest{
+        Destination:   "Lisboa",
+        DepartureDate: "2025-09-01",
+        ReturnDate:    "2025-09-10",
+        International: true,
+        CostItems:     items,
+    }
+}
+
+func TestCreateTravelRequestWithCostItems(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], costlyTrip(
+        CostItemInput{Category: CostAirfare, Description: "GRU-LIS", Amount: 300000},
+        CostItemInput{Category: CostLodging, Amount: 80000, Currency: "usd"},
+        CostItemInput{Category: CostMeals, Amount: 5000, Currency: "EUR"},
+    ))
+    assert.Equal(t, 201, w.Code)
+    
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Equal(t, int64(727500), created.EstimatedCost, "300000 + 800 USD * 5,0 + 50 EUR * 5,5")
+    assert.Len(t, created.CostItems, 3)
+    assert.Equal(t, "USD", created.CostItems[1].Currency)
+    assert.Equal(t, int64(400000), created.CostItems[1].BaseAmount)
+    
+    // O total em reais alimenta a cadeia de aprovação
+    var finance int64
+    db.Model(&ApprovalStep{}).Where("travel_request_id = ? AND name = ?", created.ID, "Financeiro").Count(&finance)
+    assert.Equal(t, int64(1), finance)
+    
+    w = performRequest(router, "GET", fmt.Sprintf("/api/travel-requests/%d", created.ID), tokens["requester"], nil)
+    var loaded TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &loaded)
+    assert.Len(t, loaded.CostItems, 3)
+}
+
+func TestCostItemsValidation(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    invalid := map[string]CreateTravelRequest{
+        "categoria desconhecida": costlyTrip(CostItemInput{Category: "souvenirs", Amount: 1000}),
+        "valor zerado":           costlyTrip(CostItemInput{Category: CostMeals, Amount: 0}),
+        "moeda não suportada":    costlyTrip(CostItemInput{Category: CostMeals, Amount: 1000, Currency: "JPY"}),
+    }
+    for name, body := range invalid {
+        w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], body)
+        assert.Equal(t, 400, w.Code, name)
+    }
+    
+    mismatch := costlyTrip(CostItemInput{Category: CostAirfare, Amount: 100000})
+    mismatch.EstimatedCost = 90000
+    w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], mismatch)
+    assert.Equal(t, 400, w.Code)
+    assert.Contains(t, w.Body.String(), "não confere com a soma")
+    
+    var count int64
+    db.Model(&CostItem{}).Count(&count)
+    assert.Equal(t, int64(0), count)
+}
+
+func TestEditingCostItemsRecomputesTotal(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], costlyTrip(
+        CostItemInput{Category: CostAirfare, Amount: 100000},
+        CostItemInput{Category: CostLodging, Amount: 50000},
+    ))
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    path := fmt.Sprintf("/api/travel-requests/%d", created.ID)
+    
+    // Campos não relacionados preservam os itens
+    w = performMergePatch(router, path, tokens["requester"], "*", `{"requester_name": "Requester Silva"}`)
+    assert.Equal(t, 200, w.Code)
+    var updated TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &updated)
+    assert.Equal(t, int64(150000), updated.EstimatedCost)
+    assert.Len(t, updated.CostItems, 2)
+    
+    w = performMergePatch(router, path, tokens["requester"], "*",
+        `{"cost_items": [{"category": "ground_transport", "amount": 20000, "currency": "EUR"}]}`)
+    assert.Equal(t, 200, w.Code)
+    json.Unmarshal(w.Body.Bytes(), &updated)
+    assert.Equal(t, int64(110000), updated.EstimatedCost)
+    
+    var items []CostItem
+    db.Where("travel_request_id = ?", created.ID).Find(&items)
+    assert.Len(t, items, 1)
+    assert.Equal(t, CostGroundTransport, items[0].Category)
+}
+
+func TestLoadExchangeRates(t *testing.T) {
+    previous := exchangeRates
+    exchangeRates = map[string]float64{"BRL": 1}
+    defer func() { exchangeRates = previous }()
+    
+    t.Setenv("EXCHANGE_RATES", "usd=5.25, GBP=6.4,EUR=abc")
+    loadExchangeRates()
+    assert.Equal(t, 5.25, exchangeRates["USD"])
+    assert.Equal(t, 6.4, exchangeRates["GBP"])
+    assert.NotContains(t, exchangeRates, "EUR")
+}
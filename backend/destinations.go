@@ -0,0 +1,213 @@
+package main
+
+import (
+    _ "embed"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+)
+
+//go:embed data/destinations.json
+var destinationsJSON []byte
+
+// País de origem da empresa; viagens com algum trecho fora dele são internacionais.
+// Sobrescrito por HOME_COUNTRY.
+var homeCountry = "BR"
+
+// Cidade do catálogo offline de destinos. O ID é o código IATA da cidade
+// (ex.: SAO), que agrupa os aeroportos dela.
+type Destination struct {
+    ID          string   `json:"id"`
+    Name        string   `json:"name"`
+    Aliases     []string `json:"aliases"`
+    Country     string   `json:"country"` // ISO 3166-1 alfa-2
+    CountryName string   `json:"country_name"`
+    Airports    []string `json:"airports"` // Códigos IATA
+    Latitude    float64  `json:"latitude"`
+    Longitude   float64  `json:"longitude"`
+    Timezone    string   `json:"timezone"` // IANA, ex.: America/Sao_Paulo
+}
+
+// Catálogo carregado do JSON embutido e índice por nome, apelido e código normalizados
+var destinationCatalog, destinationIndex = loadDestinationCatalog()
+
+func loadDestinationCatalog() ([]Destination, map[string]int) {
+    var catalog []Destination
+    if err := json.Unmarshal(destinationsJSON, &catalog); err != nil {
+        panic(fmt.Sprintf("catálogo de destinos inválido: %v", err))
+    }
+
+    index := map[string]int{}
+    for i, destination := range catalog {
+        keys := append([]string{destination.ID, destination.Name}, destination.Aliases...)
+        keys = append(keys, destination.Airports...)
+        for _, key := range keys {
+            key = normalizePlaceName(key)
+            if existing, ok := index[key]; ok && existing != i {
+                panic(fmt.Sprintf("catálogo de destinos: %q ambíguo entre %s e %s", key, catalog[existing].ID, destination.ID))
+            }
+            index[key] = i
+        }
+    }
+    return catalog, index
+}
+
+// loadDestinationConfig lê o país de origem do ambiente
+func loadDestinationConfig() {
+    homeCountry = strings.ToUpper(getEnv("HOME_COUNTRY", homeCountry))
+}
+
+var accentFolding = strings.NewReplacer(
+    "á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+    "é", "e", "è", "e", "ê", "e", "ë", "e",
+    "í", "i", "ì", "i", "î", "i", "ï", "i",
+    "ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+    "ú", "u", "ù", "u", "û", "u", "ü", "u",
+    "ç", "c", "ñ", "n",
+    ".", " ", "-", " ", "'", " ",
+)
+
+// normalizePlaceName reduz um nome à forma usada no índice: minúsculas, sem
+// acentos nem pontuação e com espaços simples ("São-Paulo" -> "sao paulo")
+func normalizePlaceName(name string) string {
+    return strings.Join(strings.Fields(accentFolding.Replace(strings.ToLower(name))), " ")
+}
+
+// lookupDestination encontra a cidade pelo nome, apelido ou código IATA.
+// Aceita também "Cidade, UF" ou "Cidade, País", usando a parte antes da vírgula.
+func lookupDestination(name string) (Destination, bool) {
+    if i, ok := destinationIndex[normalizePlaceName(name)]; ok {
+        return destinationCatalog[i], true
+    }
+    if city, _, found := strings.Cut(name, ","); found {
+        if i, ok := destinationIndex[normalizePlaceName(city)]; ok {
+            return destinationCatalog[i], true
+        }
+    }
+    return Destination{}, false
+}
+
+// canonicalPlace devolve o nome canônico e o ID do lugar no catálogo. Lugares
+// fora do catálogo mantêm o texto informado, sem ID.
+func canonicalPlace(name string) (string, string) {
+    if destination, ok := lookupDestination(name); ok {
+        return destination.Name, destination.ID
+    }
+    return strings.TrimSpace(name), ""
+}
+
+// tripInternational indica se a viagem sai do país de origem. Só é possível
+// decidir quando todos os lugares estão no catálogo; do contrário vale o
+// valor informado no pedido.
+func tripInternational(destinationID string, legs []ItineraryLeg, informed bool) bool {
+    ids := []string{destinationID}
+    for _, leg := range legs {
+        ids = append(ids, leg.OriginID, leg.DestinationID)
+    }
+
+    international := false
+    for _, id := range ids {
+        i, ok := destinationIndex[normalizePlaceName(id)]
+        if id == "" || !ok {
+            return informed
+        }
+        if destinationCatalog[i].Country != homeCountry {
+            international = true
+        }
+    }
+    return international
+}
+
+// searchDestinations busca cidades para o autocomplete. Códigos e nomes exatos
+// vêm primeiro, depois nomes e apelidos que começam com o termo e, por fim,
+// os que o contêm.
+func searchDestinations(term string, limit int) []Destination {
+    term = normalizePlaceName(term)
+    if term == "" {
+        return []Destination{}
+    }
+
+    type match struct {
+        destination Destination
+        rank        int
+    }
+    var matches []match
+    for i, destination := range destinationCatalog {
+        rank := -1
+        if index, ok := destinationIndex[term]; ok && index == i {
+            rank = 0
+        } else if strings.HasPrefix(normalizePlaceName(destination.Name), term) {
+            rank = 1
+        } else {
+            for _, name := range append([]string{destination.Name}, destination.Aliases...) {
+                normalized := normalizePlaceName(name)
+                if strings.HasPrefix(normalized, term) {
+                    rank = 2
+                    break
+                }
+                if strings.Contains(normalized, term) && rank < 0 {
+                    rank = 3
+                }
+            }
+        }
+        if rank >= 0 {
+            matches = append(matches, match{destination, rank})
+        }
+    }
+
+    sort.SliceStable(matches, func(i, j int) bool {
+        if matches[i].rank != matches[j].rank {
+            return matches[i].rank < matches[j].rank
+        }
+        return normalizePlaceName(matches[i].destination.Name) < normalizePlaceName(matches[j].destination.Name)
+    })
+
+    results := []Destination{}
+    for _, m := range matches {
+        if len(results) == limit {
+            break
+        }
+        results = append(results, m.destination)
+    }
+    return results
+}
+
+// searchDestinationsHandler atende o autocomplete de destinos (?q=, limit)
+func searchDestinationsHandler(c *gin.Context) {
+    term := c.Query("q")
+    if strings.TrimSpace(term) == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro q é obrigatório"})
+        return
+    }
+
+    limit := defaultPageLimit
+    if value := c.Query("limit"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 1 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro limit deve ser um número positivo"})
+            return
+        }
+        if parsed > maxPageLimit {
+            parsed = maxPageLimit
+        }
+        limit = parsed
+    }
+
+    c.JSON(http.StatusOK, gin.H{"data": searchDestinations(term, limit)})
+}
+
+// getDestinationHandler devolve uma cidade pelo ID, código de aeroporto ou nome
+func getDestinationHandler(c *gin.Context) {
+    destination, ok := lookupDestination(c.Param("id"))
+    if !ok {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Destino não encontrado"})
+        return
+    }
+
+    c.JSON(http.StatusOK, destination)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+    "encoding/json"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestLookupDestinationNormalizesVariants(t *testing.T) {
+    for _, name := range []string{"São Paulo", "sao paulo", "SP", "Sampa", "GRU", "cgh", "São-Paulo", "São Paulo, SP"} {
+        destination, ok := lookupDestination(name)
+        assert.True(t, ok, name)
+        assert.Equal(t, "SAO", destination.ID, name)
+    }
+    
+    destination, ok := lookupDestination("Lisbon")
+    assert.True(t, ok)
+    assert.Equal(t, "Lisboa", destination.Name)
+    assert.Equal(t, "PT", destination.Country)
+    assert.Equal(t, "Europe/Lisbon", destination.Timezone)
+    
+    _, ok = lookupDestination("Xique-Xique")
+    assert.False(t, ok)
+}
+
+func TestSearchDestinations(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    var response struct {
+        Data []Destination `json:"data"`
+    }
+    w := performRequest(router, "GET", "/api/destinations?q=GRU", token, nil)
+    assert.Equal(t, 200, w.Code)
+    json.Unmarshal(w.Body.Bytes(), &response)
+    assert.Equal(t, "SAO", response.Data[0].ID, "código de aeroporto primeiro")
+    
+    w = performRequest(router, "GET", "/api/destinations?q=por&limit=2", token, nil)
+    json.Unmarshal(w.Body.Bytes(), &response)
+    assert.Len(t, response.Data, 2)
+    assert.Equal(t, "Porto", response.Data[0].Name)
+    assert.Equal(t, "Porto Alegre", response.Data[1].Name)
+    
+    w = performRequest(router, "GET", "/api/destinations?q=brasi", token, nil)
+    json.Unmarshal(w.Body.Bytes(), &response)
+    assert.Equal(t, "BSB", response.Data[0].ID, "sem acento")
+    
+    w = performRequest(router, "GET", "/api/destinations", token, nil)
+    assert.Equal(t, 400, w.Code)
+    
+    w = performRequest(router, "GET", "/api/destinations?q=lis", "", nil)
+    assert.Equal(t, 401, w.Code)
+}
+
+func TestGetDestination(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    w := performRequest(router, "GET", "/api/destinations/lis", token, nil)
+    assert.Equal(t, 200, w.Code)
+    var destination Destination
+    json.Unmarshal(w.Body.Bytes(), &destination)
+    assert.Equal(t, "LIS", destination.ID)
+    assert.InDelta(t, 38.72, destination.Latitude, 0.01)
+    
+    w = performRequest(router, "GET", "/api/destinations/XYZ", token, nil)
+    assert.Equal(t, 404, w.Code)
+}
+
+func TestCreateTravelRequestNormalizesDestination(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    trip := CreateTravelRequest{
+        Destination:   "Lisbon",
+        DepartureDate: "2025-09-01",
+        ReturnDate:    "2025-09-05",
+    }
+    w := performRequest(router, "POST", "/api/travel-requests", token, trip)
+    assert.Equal(t, 201, w.Code)
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Equal(t, "Lisboa", created.Destination)
+    assert.Equal(t, "LIS", created.DestinationID)
+    assert.True(t, created.International, "país derivado do catálogo")
+    
+    trip.Destination = "Sao Paulo"
+    trip.International = true
+    w = performRequest(router, "POST", "/api/travel-requests", token, trip)
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Equal(t, "São Paulo", created.Destination)
+    assert.False(t, created.International)
+    
+    // Fora do catálogo: mantém o texto e o valor informado
+    trip.Destination = "Xique-Xique"
+    w = performRequest(router, "POST", "/api/travel-requests", token, trip)
+    assert.Equal(t, 201, w.Code)
+    var unknown TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &unknown)
+    assert.Equal(t, "Xique-Xique", unknown.Destination)
+    assert.Empty(t, unknown.DestinationID)
+    assert.True(t, unknown.International)
+}
+
+func TestItineraryLegsUseCatalogNames(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    trip := europeTrip()
+    trip.International = false
+    trip.Legs[0].Origin = "GRU"
+    trip.Legs[0].Destination = "Lisbon"
+    trip.Legs[2].Destination = "SP"
+    w := performRequest(router, "POST", "/api/travel-requests", token, trip)
+    assert.Equal(t, 201, w.Code, "SP e São Paulo são o mesmo lugar")
+    
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Equal(t, "LIS", created.DestinationID)
+    assert.True(t, created.International)
+    assert.Equal(t, "São Paulo", created.Legs[0].Origin)
+    assert.Equal(t, "SAO", created.Legs[0].OriginID)
+    assert.Equal(t, "MAD", created.Legs[1].DestinationID)
+    assert.Equal(t, "São Paulo", created.Legs[2].Destination)
+}
+
+func TestDestinationFilterMatchesVariants(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    createTestTravelRequest(router, token, "SP")
+    createTestTravelRequest(router, token, "São Paulo")
+    createTestTravelRequest(router, token, "sao paulo")
+    createTestTravelRequest(router, token, "Recife")
+    
+    for query, expected := range map[string]int64{
+        "destination=SP":     3,
+        "destination=GRU":    3,
+        "destination=paulo":  3,
+        "destination_id=sao": 3,
+        "destination_id=REC": 1,
+        "destination=Xique":  0,
+    } {
+        w := performRequest(router, "GET", "/api/travel-requests?"+query, token, nil)
+        assert.Equal(t, 200, w.Code)
+        var response listResponse
+        json.Unmarshal(w.Body.Bytes(), &response)
+        assert.Equal(t, expected, response.Pagination.Total, query)
+    }
+}
+
+func TestCLINormalizeStoredDestinations(t *testing.T) {
+    setupTestDB()
+    output := captureCLIOutput(t)
+    
+    db.Create(&TravelRequest{RequesterName: "Legado", Destination: "rio", Version: 1})
+    db.Create(&TravelRequest{RequesterName: "Legado", Destination: "Xique-Xique", Version: 1})
+    db.Create(&ItineraryLeg{TravelRequestID: 1, Position: 1, Origin: "sao paulo", Destination: "rio"})
+    
+    err := runRequestCommand([]string{"normalize-destinations"})
+    assert.NoError(t, err)
+    assert.Contains(t, output.String(), "1 pedidos, 1 trechos")
+    
+    var request TravelRequest
+    db.First(&request, 1)
+    assert.Equal(t, "Rio de Janeiro", request.Destination)
+    assert.Equal(t, "RIO", request.DestinationID)
+    assert.Equal(t, 1, request.Version)
+    
+    var leg ItineraryLeg
+    db.First(&leg)
+    assert.Equal(t, "SAO", leg.OriginID)
+    assert.Equal(t, "Rio de Janeiro", leg.Destination)
+}
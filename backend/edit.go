@@ -0,0 +1,273 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "github.com/gin-gonic/gin/binding"
+    "gorm.io/gorm"
+)
+
+// Status em que o pedido ainda pode ser editado
+var editableStatuses = map[string]bool{
+    StatusDraft:     true,
+    StatusRequested: true,
+    StatusRejected:  true,
+}
+
+// Campos editáveis do pedido (mesma validação da criação)
+type UpdateTravelRequest struct {
+    TravelerID    uint   `json:"traveler_id"` // Vazio: mantém o viajante atual
+    Destination   string `json:"destination" binding:"required_without=Legs"`
+    DepartureDate string `json:"departure_date" binding:"required_without=Legs"`
+    ReturnDate    string `json:"return_date" binding:"required_without=Legs"`
+    Legs          []ItineraryLegInput `json:"legs" binding:"omitempty,dive"`
+    EstimatedCost int64  `json:"estimated_cost" binding:"min=0"` // Centavos; calculado quando há itens de custo
+    CostItems     []CostItemInput `json:"cost_items" binding:"omitempty,dive"`
+    International bool   `json:"international"`
+    CostCenter    string `json:"cost_center"`
+}
+
+// editableFields representa o estado atual do pedido no formato de edição.
+// Valores derivados (total dos itens de custo, datas do itinerário) ficam de
+// fora, para serem recalculados.
+func editableFields(request TravelRequest) UpdateTravelRequest {
+    fields := UpdateTravelRequest{
+        TravelerID:    request.TravelerID,
+        Destination:   request.Destination,
+        DepartureDate: request.DepartureDate.Format("2006-01-02"),
+        ReturnDate:    request.ReturnDate.Format("2006-01-02"),
+        EstimatedCost: request.EstimatedCost,
+        International: request.International,
+        CostCenter:    request.CostCenter,
+    }
+    if len(request.CostItems) > 0 {
+        fields.EstimatedCost = 0
+        fields.CostItems = costItemInputs(request.CostItems)
+    }
+    if len(request.Legs) > 0 {
+        fields.DepartureDate = ""
+        fields.ReturnDate = ""
+        fields.Legs = itineraryInputs(request.Legs)
+    }
+    return fields
+}
+
+// applyMergePatch aplica um JSON Merge Patch (RFC 7396) sobre o documento
+func applyMergePatch(target map[string]interface{}, patch map[string]interface{}) {
+    for key, value := range patch {
+        if value == nil {
+            delete(target, key)
+            continue
+        }
+        if patchObject, ok := value.(map[string]interface{}); ok {
+            targetObject, ok := target[key].(map[string]interface{})
+            if !ok {
+                targetObject = map[string]interface{}{}
+            }
+            applyMergePatch(targetObject, patchObject)
+            target[key] = targetObject
+            continue
+        }
+        target[key] = value
+    }
+}
+
+func updateTravelRequestHandler(c *gin.Context) {
+    request, ok := loadEditableTravelRequest(c)
+    if !ok {
+        return
+    }
+
+    var req UpdateTravelRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    saveTravelRequestEdit(c, request, req)
+}
+
+func patchTravelRequestHandler(c *gin.Context) {
+    request, ok := loadEditableTravelRequest(c)
+    if !ok {
+        return
+    }
+
+    body, err := io.ReadAll(c.Request.Body)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Corpo da requisição inválido"})
+        return
+    }
+
+    var patch map[string]interface{}
+    if err := json.Unmarshal(body, &patch); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "JSON Merge Patch inválido: o corpo deve ser um objeto JSON"})
+        return
+    }
+
+    // Documento atual -> aplica o patch -> valida como uma edição completa
+    var document map[string]interface{}
+    current, _ := json.Marshal(editableFields(request))
+    json.Unmarshal(current, &document)
+    applyMergePatch(document, patch)
+
+    merged, _ := json.Marshal(document)
+    var req UpdateTravelRequest
+    if err := json.Unmarshal(merged, &req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if err := binding.Validator.ValidateStruct(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    saveTravelRequestEdit(c, request, req)
+}
+
+// loadEditableTravelRequest busca o pedido e verifica se o usuário pode editá-lo
+func loadEditableTravelRequest(c *gin.Context) (TravelRequest, bool) {
+    userID, _ := c.Get("user_id")
+
+    request, err := findVisibleTravelRequest(c, c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
+        return request, false
+    }
+
+    if !isRequestOwner(request, userID.(uint)) && c.GetString("user_role") != RoleAdmin {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o dono do pedido pode editá-lo"})
+        return request, false
+    }
+
+    if !editableStatuses[request.Status] {
+        c.JSON(http.StatusConflict, gin.H{
+            "error":          fmt.Sprintf("Pedido com status '%s' não pode ser editado", request.Status),
+            "current_status": request.Status,
+        })
+        return request, false
+    }
+
+    if !checkIfMatch(c, request) {
+        return request, false
+    }
+
+    return request, true
+}
+
+// saveTravelRequestEdit valida e grava a edição. Mudanças em campos materiais
+// (viajante, destino, itinerário, datas, custo, viagem internacional, centro de
+// custo) reiniciam a cadeia de aprovação.
+func saveTravelRequestEdit(c *gin.Context, request TravelRequest, req UpdateTravelRequest) {
+    userID, _ := c.Get("user_id")
+
+    travelerChanged := req.TravelerID != 0 && req.TravelerID != request.TravelerID
+    if travelerChanged {
+        user, err := currentUser(c)
+        if err != nil {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+            return
+        }
+        traveler, err := resolveTraveler(user, req.TravelerID)
+        if err != nil {
+            respondTravelerError(c, err)
+            return
+        }
+        request.TravelerID = traveler.ID
+        request.RequesterName = traveler.Name
+    }
+
+    legs, destination, departureDate, returnDate, err := planTrip(req.Destination, req.DepartureDate, req.ReturnDate, req.Legs)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    destination, destinationID := canonicalPlace(destination)
+    international := tripInternational(destinationID, legs, req.International)
+
+    costItems, estimatedCost, err := buildCostItems(req.CostItems, req.EstimatedCost)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    material := travelerChanged ||
+        request.Destination != destination ||
+        !sameItinerary(request.Legs, legs) ||
+        !request.DepartureDate.Equal(departureDate) ||
+        !request.ReturnDate.Equal(returnDate) ||
+        request.EstimatedCost != estimatedCost ||
+        request.International != international
+
+    request.Destination = destination
+    request.DestinationID = destinationID
+    request.Legs = legs
+    request.DepartureDate = departureDate
+    request.ReturnDate = returnDate
+    request.EstimatedCost = estimatedCost
+    request.CostItems = costItems
+    request.International = international
+    if costCenter := strings.TrimSpace(req.CostCenter); costCenter != "" && costCenter != request.CostCenter {
+        user, err := currentUser(c)
+        if err != nil {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+            return
+        }
+        var traveler User
+        if err := db.Where("id = ?", request.TravelerID).First(&traveler).Error; err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Viajante não encontrado"})
+            return
+        }
+        if err := validateCostCenter(user, traveler, costCenter); err != nil {
+            respondCostCenterError(c, err)
+            return
+        }
+        request.CostCenter = costCenter
+        // O orçamento consumido e quem aprova dependem do centro de custo
+        material = true
+    }
+
+    if err := applyTravelPolicy(&request); err != nil {
+        respondPolicyViolation(c, err)
+        return
+    }
+
+    resetApprovals := material && request.Status == StatusRequested
+    err = db.Transaction(func(tx *gorm.DB) error {
+        if err := checkTripOverlaps(tx, &request); err != nil {
+            return err
+        }
+        if err := saveTravelRequest(tx, &request); err != nil {
+            return err
+        }
+        if err := replaceCostItems(tx, &request); err != nil {
+            return err
+        }
+        if err := replaceItinerary(tx, &request); err != nil {
+            return err
+        }
+        if !resetApprovals {
+            return nil
+        }
+        if err := resetApprovalChain(tx, request, userID.(uint)); err != nil {
+            return err
+        }
+        return recordStatusChange(tx, request, request.Status, userID.(uint), "Pedido editado; aprovações reiniciadas")
+    })
+    if err != nil {
+        if !respondTripOverlap(c, err) {
+            respondSaveError(c, err, "Erro ao atualizar pedido de viagem")
+        }
+        return
+    }
+
+    print_status(fmt.Sprintf("Pedido %d editado por usuário %d", request.ID, userID))
+    setETag(c, request)
+    c.JSON(http.StatusOK, request)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+func performMergePatch(router *gin.Engine, path, token, ifMatch, body string) *httptest.ResponseRecorder {
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("PATCH", path, strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/merge-patch+json")
+    req.Header.Set("Authorization", "Bearer "+token)
+    req.Header.Set("If-Match", ifMatch)
+    router.ServeHTTP(w, req)
+    
+    return w
+}
+
+func TestUpdateTravelRequest(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    
+    id := createTestTravelRequest(router, ownerToken, "Recfie")
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    
+    w := performConditional(router, "PUT", path, ownerToken, "*", UpdateTravelRequest{
+        Destination:   "Recife",
+        DepartureDate: "2025-08-16",
+        ReturnDate:    "2025-08-14",
+    })
+    assert.Equal(t, 400, w.Code)
+    assert.Contains(t, w.Body.String(), "Data de volta deve ser posterior")
+    
+    w = performConditional(router, "PUT", path, ownerToken, "*", UpdateTravelRequest{
+        Destination:   "Recife",
+        DepartureDate: "2025-08-16",
+        ReturnDate:    "2025-08-21",
+    })
+    assert.Equal(t, 200, w.Code)
+    
+    var updated TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &updated)
+    assert.Equal(t, "Recife", updated.Destination)
+    assert.Equal(t, "2025-08-16", updated.DepartureDate.Format("2006-01-02"))
+}
+
+func TestPatchTravelRequestWithMergePatch(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    
+    id := createTestTravelRequest(router, ownerToken, "Recfie")
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    
+    w := performMergePatch(router, path, ownerToken, "*", `{"destination": "Recife"}`)
+    assert.Equal(t, 200, w.Code)
+    
+    var updated TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &updated)
+    assert.Equal(t, "Recife", updated.Destination)
+    assert.Equal(t, "Owner", updated.RequesterName, "nome derivado do viajante")
+    assert.Equal(t, "2025-08-15", updated.DepartureDate.Format("2006-01-02"))
+    
+    // null remove o campo, que é obrigatório
+    w = performMergePatch(router, path, ownerToken, "*", `{"destination": null}`)
+    assert.Equal(t, 400, w.Code)
+    
+    w = performMergePatch(router, path, ownerToken, "*", `{"return_date": "2025-08-01"}`)
+    assert.Equal(t, 400, w.Code)
+}
+
+func TestEditOnlyAllowedForOwnerInEditableState(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    adminToken := registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    sharedToken := registerAndLogin(router, "Shared", "shared@example.com", "")
+    
+    id := createTestTravelRequest(router, ownerToken, "Recife")
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    performRequest(router, "POST", path+"/shares", ownerToken, ShareTravelRequest{UserID: 3})
+    
+    w := performMergePatch(router, path, sharedToken, "*", `{"destination": "Olinda"}`)
+    assert.Equal(t, 403, w.Code)
+    
+    performConditional(router, "PUT", path+"/status", adminToken, "*", UpdateStatusRequest{Status: StatusApproved})
+    
+    w = performMergePatch(router, path, ownerToken, "*", `{"destination": "Olinda"}`)
+    assert.Equal(t, 409, w.Code)
+}
+
+func TestMaterialEditResetsApprovalProgress(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    id := createCostlyTravelRequest(router, tokens["requester"])
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    
+    w := performConditional(router, "PUT", path+"/status", tokens["manager"], "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 200, w.Code)
+    
+    // Mudar o nome não reinicia a cadeia
+    performMergePatch(router, path, tokens["requester"], "*", `{"requester_name": "Requester Silva"}`)
+    step, _ := currentApprovalStep(id)
+    assert.Equal(t, "Financeiro", step.Name)
+    
+    // Mudar o destino reinicia a cadeia desde o gestor
+    w = performMergePatch(router, path, tokens["requester"], "*", `{"destination": "Porto"}`)
+    assert.Equal(t, 200, w.Code)
+    
+    step, _ = currentApprovalStep(id)
+    assert.Equal(t, "Gestor direto", step.Name)
+    
+    var pending int64
+    db.Model(&ApprovalStep{}).Where("travel_request_id = ? AND status = ?", id, StepPending).Count(&pending)
+    assert.Equal(t, int64(3), pending)
+    
+    // Trocar o centro de custo também reinicia: muda o orçamento consumido
+    w = performConditional(router, "PUT", path+"/status", tokens["manager"], "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 200, w.Code)
+    db.Model(&User{}).Where("email = ?", "director@example.com").Update("department", "Marketing")
+    w = performMergePatch(router, path, tokens["admin"], "*", `{"cost_center": "Marketing"}`)
+    assert.Equal(t, 200, w.Code)
+    
+    step, _ = currentApprovalStep(id)
+    assert.Equal(t, "Gestor direto", step.Name)
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5"
+    "gorm.io/gorm"
+)
+
+// Canal do LISTEN/NOTIFY que avisa todas as réplicas de um novo evento
+const eventsNotifyChannel = "travel_request_events"
+
+// Chave do advisory lock que serializa a numeração do stream entre réplicas
+const streamSequenceLockKey = 7_240_019
+
+const (
+    eventSubscriberBuffer = 64
+    eventReplayLimit      = 500
+    eventPollInterval     = 2 * time.Second
+)
+
+// Intervalo dos comentários ": ping", que também revalidam o token da conexão
+var eventKeepAlive = 25 * time.Second
+
+// eventHub distribui os eventos do outbox para as conexões SSE desta réplica.
+// Os eventos circulam em lotes para que cada conexão confira a visibilidade
+// de um lote inteiro com uma única consulta.
+type eventHub struct {
+    mu          sync.Mutex
+    subscribers map[chan []TravelRequestEvent]struct{}
+
+    pollMu  sync.Mutex
+    lastSeq uint64 // posição do último evento publicado
+}
+
+var eventStream = newEventHub()
+
+func newEventHub() *eventHub {
+    return &eventHub{subscribers: map[chan []TravelRequestEvent]struct{}{}}
+}
+
+func (h *eventHub) subscribe() chan []TravelRequestEvent {
+    ch := make(chan []TravelRequestEvent, eventSubscriberBuffer)
+    h.mu.Lock()
+    h.subscribers[ch] = struct{}{}
+    h.mu.Unlock()
+    return ch
+}
+
+func (h *eventHub) unsubscribe(ch chan []TravelRequestEvent) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    if _, ok := h.subscribers[ch]; ok {
+        delete(h.subscribers, ch)
+        close(ch)
+    }
+}
+
+// broadcast entrega o lote de eventos a todos os inscritos. Um cliente lento
+// demais é desconectado em vez de perder eventos: ele reconecta com Last-Event-ID.
+func (h *eventHub) broadcast(events ...TravelRequestEvent) {
+    if len(events) == 0 {
+        return
+    }
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for ch := range h.subscribers {
+        select {
+        case ch <- events:
+        default:
+            delete(h.subscribers, ch)
+            close(ch)
+        }
+    }
+}
+
+// poll numera os eventos confirmados desde a última consulta e os publica em
+// ordem, página a página. Chamado a cada NOTIFY no Postgres e periodicamente
+// nos demais bancos.
+func (h *eventHub) poll() error {
+    h.pollMu.Lock()
+    defer h.pollMu.Unlock()
+
+    if err := assignStreamSequence(); err != nil {
+        return err
+    }
+    for {
+        events, last, full, err := loadStreamEvents(h.lastSeq)
+        if err != nil {
+            return err
+        }
+        h.broadcast(events...)
+        h.lastSeq = last
+        if !full {
+            return nil
+        }
+    }
+}
+
+var streamSequenceMu sync.Mutex
+
+// assignStreamSequence dá posição no stream aos eventos que ainda não têm.
+// A consulta só enxerga linhas já confirmadas e a numeração é serializada
+// (mutex nesta réplica, advisory lock entre réplicas), então um evento cujo ID
+// foi reservado antes mas que confirmou depois recebe uma posição maior e não
+// fica para trás do cursor dos clientes.
+func assignStreamSequence() error {
+    streamSequenceMu.Lock()
+    defer streamSequenceMu.Unlock()
+
+    return db.Transaction(func(tx *gorm.DB) error {
+        if tx.Dialector.Name() == "postgres" {
+            if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", streamSequenceLockKey).Error; err != nil {
+                return err
+            }
+        }
+
+        var pending []uint
+        if err := tx.Model(&OutboxEvent{}).Where("stream_seq IS NULL").Order("id ASC").Pluck("id", &pending).Error; err != nil {
+            return err
+        }
+        if len(pending) == 0 {
+            return nil
+        }
+
+        var last uint64
+        if err := tx.Model(&OutboxEvent{}).Select("COALESCE(MAX(stream_seq), 0)").Scan(&last).Error; err != nil {
+            return err
+        }
+        for _, id := range pending {
+            last++
+            if err := tx.Model(&OutboxEvent{}).Where("id = ?", id).Update("stream_seq", last).Error; err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+// loadStreamEvents lê a página de eventos posteriores à posição informada.
+// Devolve também a posição do último lido e se a página veio cheia; eventos
+// com payload ilegível são pulados.
+func loadStreamEvents(after uint64) ([]TravelRequestEvent, uint64, bool, error) {
+    var entries []OutboxEvent
+    if err := db.Where("stream_seq > ?", after).Order("stream_seq ASC").Limit(eventReplayLimit).Find(&entries).Error; err != nil {
+        return nil, after, false, err
+    }
+    events := make([]TravelRequestEvent, 0, len(entries))
+    for _, entry := range entries {
+        event, err := decodeOutboxEvent(entry)
+        if err != nil {
+            log.Printf("Evento %d ignorado no stream: %v", entry.ID, err)
+        } else {
+            events = append(events, event)
+        }
+        after = *entry.StreamSeq
+    }
+    return events, after, len(entries) == eventReplayLimit, nil
+}
+
+func decodeOutboxEvent(entry OutboxEvent) (TravelRequestEvent, error) {
+    var event TravelRequestEvent
+    if err := json.Unmarshal([]byte(entry.Payload), &event); err != nil {
+        return event, err
+    }
+    event.ID = entry.ID
+    if entry.StreamSeq != nil {
+        event.StreamSeq = *entry.StreamSeq
+    }
+    return event, nil
+}
+
+// notifyEventListeners avisa as réplicas do novo evento. Com Postgres o
+// NOTIFY é transacional: só é entregue se a transação for confirmada.
+func notifyEventListeners(tx *gorm.DB, eventID uint) error {
+    if tx.Dialector.Name() != "postgres" {
+        return nil
+    }
+    return tx.Exec("SELECT pg_notify(?, ?)", eventsNotifyChannel, strconv.FormatUint(uint64(eventID), 10)).Error
+}
+
+// startEventHub alimenta o hub: LISTEN no Postgres ou polling do outbox
+func startEventHub() {
+    if err := assignStreamSequence(); err != nil {
+        log.Printf("Erro ao numerar eventos: %v", err)
+    }
+    var last uint64
+    db.Model(&OutboxEvent{}).Select("COALESCE(MAX(stream_seq), 0)").Scan(&last)
+    eventStream.lastSeq = last
+
+    if db.Dialector.Name() == "postgres" {
+        go listenForEvents(databaseDSN())
+        print_status("Stream de eventos usando LISTEN/NOTIFY")
+        return
+    }
+
+    go func() {
+        ticker := time.NewTicker(eventPollInterval)
+        defer ticker.Stop()
+        for range ticker.C {
+            if err := eventStream.poll(); err != nil {
+                log.Printf("Erro ao buscar eventos: %v", err)
+            }
+        }
+    }()
+    print_status("Stream de eventos usando polling do outbox")
+}
+
+// listenForEvents mantém uma conexão dedicada escutando o canal de eventos,
+// reconectando em caso de falha. O NOTIFY só indica que há eventos novos: a
+// ordem de entrega vem da numeração do stream.
+func listenForEvents(dsn string) {
+    ctx := context.Background()
+    for {
+        err := func() error {
+            conn, err := pgx.Connect(ctx, dsn)
+            if err != nil {
+                return err
+            }
+            defer conn.Close(ctx)
+
+            if _, err := conn.Exec(ctx, "LISTEN "+eventsNotifyChannel); err != nil {
+                return err
+            }
+            // Recupera o que foi confirmado enquanto a conexão estava caída
+            if err := eventStream.poll(); err != nil {
+                log.Printf("Erro ao buscar eventos: %v", err)
+            }
+            for {
+                if _, err := conn.WaitForNotification(ctx); err != nil {
+                    return err
+                }
+                if err := eventStream.poll(); err != nil {
+                    log.Printf("Erro ao buscar eventos: %v", err)
+                }
+            }
+        }()
+        log.Printf("Conexão LISTEN perdida: %v; reconectando em 3s", err)
+        time.Sleep(3 * time.Second)
+    }
+}
+
+// tokenFromQuery aceita o token em "?access_token=", já que o EventSource do
+// navegador não envia headers personalizados. O logger de acesso mascara o
+// parâmetro (accessLogFormatter).
+func tokenFromQuery() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if c.GetHeader("Authorization") == "" {
+            if token := c.Query("access_token"); token != "" {
+                c.Request.Header.Set("Authorization", "Bearer "+token)
+            }
+        }
+        c.Next()
+    }
+}
+
+// accessLogFormatter segue o formato do logger padrão do gin, mas sem expor o
+// JWT de "?access_token=" nos logs
+func accessLogFormatter(param gin.LogFormatterParams) string {
+    var statusColor, methodColor, resetColor string
+    if param.IsOutputColor() {
+        statusColor = param.StatusCodeColor()
+        methodColor = param.MethodColor()
+        resetColor = param.ResetColor()
+    }
+    if param.Latency > time.Minute {
+        param.Latency = param.Latency.Truncate(time.Second)
+    }
+    return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+        param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+        statusColor, param.StatusCode, resetColor,
+        param.Latency,
+        param.ClientIP,
+        methodColor, param.Method, resetColor,
+        redactAccessToken(param.Path),
+        param.ErrorMessage,
+    )
+}
+
+// redactAccessToken troca o valor de access_token na query string do caminho
+func redactAccessToken(path string) string {
+    base, rawQuery, ok := strings.Cut(path, "?")
+    if !ok {
+        return path
+    }
+    query, err := url.ParseQuery(rawQuery)
+    if err != nil {
+        // Query malformada: não dá para garantir que o token ficaria de fora
+        return base + "?[REDACTED]"
+    }
+    if !query.Has("access_token") {
+        return path
+    }
+    query.Set("access_token", "[REDACTED]")
+    return base + "?" + query.Encode()
+}
+
+// streamEventsHandler envia por SSE os eventos dos pedidos visíveis ao
+// usuário. O id de cada mensagem é a posição do evento no stream; com
+// Last-Event-ID (ou ?last_event_id=) reenvia o que foi perdido.
+func streamEventsHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    lastEventID := c.GetHeader("Last-Event-ID")
+    if lastEventID == "" {
+        lastEventID = c.Query("last_event_id")
+    }
+    var resumeFrom uint64
+    if lastEventID != "" {
+        if resumeFrom, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID inválido"})
+            return
+        }
+    }
+
+    // Inscreve antes do replay para não perder eventos entre as duas etapas
+    ch := eventStream.subscribe()
+    defer eventStream.unsubscribe(ch)
+
+    c.Header("Content-Type", "text/event-stream")
+    c.Header("Cache-Control", "no-cache")
+    c.Header("Connection", "keep-alive")
+    c.Header("X-Accel-Buffering", "no")
+    c.Status(http.StatusOK)
+    c.Writer.Flush()
+
+    sent := resumeFrom
+    if lastEventID != "" {
+        if err := assignStreamSequence(); err != nil {
+            log.Printf("Erro ao numerar eventos: %v", err)
+        }
+        for {
+            events, last, full, err := loadStreamEvents(sent)
+            if err != nil {
+                log.Printf("Erro ao reenviar eventos: %v", err)
+                return
+            }
+            if _, err := writeVisibleEvents(c, user, events, sent); err != nil {
+                return
+            }
+            sent = last
+            c.Writer.Flush()
+            if !full {
+                break
+            }
+        }
+    }
+
+    keepAlive := time.NewTicker(eventKeepAlive)
+    defer keepAlive.Stop()
+
+    for {
+        select {
+        case <-c.Request.Context().Done():
+            return
+        case <-keepAlive.C:
+            // A conexão dura mais que o access token: encerra quando ele expira
+            // ou quando a sessão é revogada, e o cliente reconecta com um novo
+            if !streamSessionActive(c) {
+                return
+            }
+            fmt.Fprint(c.Writer, ": ping\n\n")
+            c.Writer.Flush()
+        case events, ok := <-ch:
+            if !ok {
+                // Desconectado pelo hub; o cliente retoma com Last-Event-ID
+                return
+            }
+            // Junta os lotes que já estão na fila para conferir tudo de uma vez
+            for pending := true; pending; {
+                select {
+                case more, ok := <-ch:
+                    if !ok {
+                        return
+                    }
+                    events = append(events, more...)
+                default:
+                    pending = false
+                }
+            }
+            if sent, err = writeVisibleEvents(c, user, events, sent); err != nil {
+                // Encerra sem pular o lote; o cliente retoma com Last-Event-ID
+                return
+            }
+            c.Writer.Flush()
+        }
+    }
+}
+
+// streamSessionActive revalida o token da conexão: assinatura, expiração e
+// sessão não revogada
+func streamSessionActive(c *gin.Context) bool {
+    token, err := jwtKeys.parse(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+    if err != nil || !token.Valid {
+        return false
+    }
+    return !isTokenFamilyRevoked(c.GetString("session_id"))
+}
+
+// writeVisibleEvents escreve os eventos posteriores à posição "sent" cujos
+// pedidos o usuário pode ver, com uma única consulta de visibilidade para o
+// lote. Devolve a posição do último evento considerado.
+func writeVisibleEvents(c *gin.Context, user User, events []TravelRequestEvent, sent uint64) (uint64, error) {
+    ids := []uint{}
+    for _, event := range events {
+        if event.StreamSeq > sent {
+            ids = append(ids, event.Request.ID)
+        }
+    }
+    if len(ids) == 0 {
+        return sent, nil
+    }
+
+    var visibleIDs []uint
+    if err := visibleTravelRequestsFor(user).Where("travel_requests.id IN ?", ids).Pluck("travel_requests.id", &visibleIDs).Error; err != nil {
+        log.Printf("Erro ao verificar visibilidade de eventos: %v", err)
+        return sent, err
+    }
+    visible := map[uint]bool{}
+    for _, id := range visibleIDs {
+        visible[id] = true
+    }
+
+    for _, event := range events {
+        if event.StreamSeq <= sent {
+            continue
+        }
+        if visible[event.Request.ID] {
+            writeSSEEvent(c, event)
+        }
+        sent = event.StreamSeq
+    }
+    return sent, nil
+}
+
+func writeSSEEvent(c *gin.Context, event TravelRequestEvent) {
+    data, err := json.Marshal(newEventPayload(event))
+    if err != nil {
+        return
+    }
+    fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.StreamSeq, event.Type, data)
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/stretchr/testify/assert"
+)
+
+type sseMessage struct {
+    ID    string
+    Event string
+    Data  string
+}
+
+// openEventStream conecta ao /api/events e devolve um leitor de mensagens SSE
+func openEventStream(t *testing.T, server *httptest.Server, token, lastEventID string) (*http.Response, func() sseMessage) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    t.Cleanup(cancel)
+    
+    req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/events?access_token="+token, nil)
+    if lastEventID != "" {
+        req.Header.Set("Last-Event-ID", lastEventID)
+    }
+    resp, err := http.DefaultClient.Do(req)
+    assert.NoError(t, err)
+    t.Cleanup(func() { resp.Body.Close() })
+    
+    reader := bufio.NewReader(resp.Body)
+    next := func() sseMessage {
+        var msg sseMessage
+        for {
+            line, err := reader.ReadString('\n')
+            if err != nil {
+                return msg
+            }
+            line = strings.TrimRight(line, "\n")
+            switch {
+            case line == "" && msg.ID != "":
+                return msg
+            case strings.HasPrefix(line, "id: "):
+                msg.ID = strings.TrimPrefix(line, "id: ")
+            case strings.HasPrefix(line, "event: "):
+                msg.Event = strings.TrimPrefix(line, "event: ")
+            case strings.HasPrefix(line, "data: "):
+                msg.Data = strings.TrimPrefix(line, "data: ")
+            }
+        }
+    }
+    return resp, next
+}
+
+func TestEventStreamRequiresAuthentication(t *testing.T) {
+    setupTestDB()
+    server := httptest.NewServer(setupTestRouter())
+    defer server.Close()
+    
+    resp, err := http.Get(server.URL + "/api/events")
+    assert.NoError(t, err)
+    resp.Body.Close()
+    assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestEventStreamReplaysOnlyVisibleEvents(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    server := httptest.NewServer(router)
+    defer server.Close()
+    
+    tokenA := registerAndLogin(router, "User A", "a@example.com", "")
+    tokenB := registerAndLogin(router, "User B", "b@example.com", "")
+    createTestTravelRequest(router, tokenA, "Recife")
+    createTestTravelRequest(router, tokenB, "Natal")
+    createTestTravelRequest(router, tokenA, "Salvador")
+    
+    resp, next := openEventStream(t, server, tokenB, "0")
+    assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+    
+    // Cada criação grava request.created e approval.requested
+    msg := next()
+    assert.Equal(t, "3", msg.ID)
+    assert.Equal(t, EventRequestCreated, msg.Event)
+    assert.Contains(t, msg.Data, `"destination":"Natal"`)
+    assert.NotContains(t, msg.Data, "recipients")
+    
+    msg = next()
+    assert.Equal(t, "4", msg.ID)
+    assert.Equal(t, EventApprovalRequested, msg.Event)
+}
+
+func TestEventStreamPushesLiveEvents(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    server := httptest.NewServer(router)
+    defer server.Close()
+    
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    previous := eventStream
+    eventStream = newEventHub()
+    defer func() { eventStream = previous }()
+    eventStream.poll()
+    
+    _, next := openEventStream(t, server, tokens["manager"], "")
+    
+    // A inscrição acontece assim que os headers são enviados
+    w := performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/1/approve", requestID), tokens["manager"], "*", nil)
+    assert.Equal(t, 200, w.Code)
+    assert.NoError(t, eventStream.poll())
+    
+    msg := next()
+    assert.Equal(t, "3", msg.ID)
+    assert.Equal(t, EventRequestStatusChanged, msg.Event)
+    assert.Contains(t, msg.Data, `"status":"aprovado"`)
+}
+
+func TestEventStreamFiltersBatchByVisibility(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    server := httptest.NewServer(router)
+    defer server.Close()
+    
+    outsider := registerAndLogin(router, "Outsider", "outsider@example.com", "")
+    previous := eventStream
+    eventStream = newEventHub()
+    defer func() { eventStream = previous }()
+    eventStream.poll()
+    
+    _, next := openEventStream(t, server, tokens["manager"], "")
+    
+    // Os quatro eventos chegam no mesmo lote; só os do pedido da equipe passam
+    createTestTravelRequest(router, outsider, "Natal")
+    visibleID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    assert.NoError(t, eventStream.poll())
+    
+    msg := next()
+    assert.Equal(t, "3", msg.ID)
+    assert.Equal(t, EventRequestCreated, msg.Event)
+    assert.Contains(t, msg.Data, fmt.Sprintf(`"id":%d`, visibleID))
+    
+    msg = next()
+    assert.Equal(t, "4", msg.ID)
+    assert.Equal(t, EventApprovalRequested, msg.Event)
+}
+
+func TestAccessTokenIsRedactedFromLogs(t *testing.T) {
+    setupTestDB()
+    var logs bytes.Buffer
+    router := gin.New()
+    router.Use(gin.LoggerWithConfig(gin.LoggerConfig{Formatter: accessLogFormatter, Output: &logs}))
+    registerRoutes(router)
+    
+    performRequest(router, "GET", "/api/events?access_token=eyJhbGciOiJIUzI1NiJ9.secret&last_event_id=7", "", nil)
+    assert.Contains(t, logs.String(), "/api/events?access_token=%5BREDACTED%5D&last_event_id=7")
+    assert.NotContains(t, logs.String(), "eyJhbGciOiJIUzI1NiJ9")
+    
+    assert.Equal(t, "/api/travel-requests?status=aprovado", redactAccessToken("/api/travel-requests?status=aprovado"))
+    assert.Equal(t, "/api/events?[REDACTED]", redactAccessToken("/api/events?access_token=abc%zz"))
+}
+
+func TestSlowSubscriberIsDisconnected(t *testing.T) {
+    hub := newEventHub()
+    ch := hub.subscribe()
+    
+    for i := 0; i <= eventSubscriberBuffer; i++ {
+        hub.broadcast(TravelRequestEvent{ID: uint(i + 1)})
+    }
+    
+    received := 0
+    for range ch {
+        received++
+    }
+    assert.Equal(t, eventSubscriberBuffer, received, "canal fechado depois de encher")
+    hub.unsubscribe(ch)
+}
+
+// outboxEntry monta uma linha do outbox para o pedido, com o ID informado
+func outboxEntry(t *testing.T, id, requestID uint) OutboxEvent {
+    var request TravelRequest
+    assert.NoError(t, db.First(&request, requestID).Error)
+    payload, err := json.Marshal(TravelRequestEvent{Type: EventRequestStatusChanged, Request: request, OccurredAt: time.Now()})
+    assert.NoError(t, err)
+    return OutboxEvent{ID: id, EventType: EventRequestStatusChanged, TravelRequestID: requestID, Payload: string(payload), Status: OutboxDelivered, NextAttemptAt: time.Now()}
+}
+
+func TestEventStreamDeliversLateCommittedEvents(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    server := httptest.NewServer(router)
+    defer server.Close()
+    
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    previous := eventStream
+    eventStream = newEventHub()
+    defer func() { eventStream = previous }()
+    eventStream.poll()
+    
+    _, next := openEventStream(t, server, tokens["manager"], "")
+    
+    entry := outboxEntry(t, 100, requestID)
+    assert.NoError(t, db.Create(&entry).Error)
+    assert.NoError(t, eventStream.poll())
+    
+    msg := next()
+    assert.Equal(t, "3", msg.ID)
+    assert.Contains(t, msg.Data, `"id":100`)
+    
+    // ID reservado antes do 100 por uma transação que só confirmou agora
+    late := outboxEntry(t, 50, requestID)
+    assert.NoError(t, db.Create(&late).Error)
+    assert.NoError(t, eventStream.poll())
+    
+    msg = next()
+    assert.Equal(t, "4", msg.ID)
+    assert.Contains(t, msg.Data, `"id":50`)
+}
+
+func TestEventStreamReplayPagesPastLimit(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    server := httptest.NewServer(router)
+    defer server.Close()
+    
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    entries := []OutboxEvent{}
+    for i := 0; i < eventReplayLimit+10; i++ {
+        entries = append(entries, outboxEntry(t, uint(100+i), requestID))
+    }
+    assert.NoError(t, db.CreateInBatches(&entries, 100).Error)
+    
+    _, next := openEventStream(t, server, tokens["manager"], "0")
+    
+    total := eventReplayLimit + 12
+    var msg sseMessage
+    for i := 1; i <= total; i++ {
+        msg = next()
+        if !assert.Equal(t, strconv.Itoa(i), msg.ID) {
+            return
+        }
+    }
+    assert.Contains(t, msg.Data, fmt.Sprintf(`"id":%d`, 100+eventReplayLimit+9))
+}
+
+// withKeepAlive encurta o intervalo de ping, que também revalida o token
+func withKeepAlive(t *testing.T, interval time.Duration) {
+    previous := eventKeepAlive
+    eventKeepAlive = interval
+    t.Cleanup(func() { eventKeepAlive = previous })
+}
+
+func TestEventStreamClosesWhenSessionIsRevoked(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    server := httptest.NewServer(router)
+    defer server.Close()
+    withKeepAlive(t, 50*time.Millisecond)
+    
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    resp, _ := openEventStream(t, server, token, "")
+    assert.Equal(t, 200, resp.StatusCode)
+    
+    w := performRequest(router, "POST", "/api/auth/logout", token, nil)
+    assert.Equal(t, 200, w.Code)
+    
+    // O servidor encerra o stream no próximo ping (antes do timeout do cliente)
+    _, err := io.ReadAll(resp.Body)
+    assert.NoError(t, err)
+}
+
+func TestEventStreamClosesWhenTokenExpires(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    server := httptest.NewServer(router)
+    defer server.Close()
+    withKeepAlive(t, 50*time.Millisecond)
+    
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    parsed, err := jwtKeys.parse(token)
+    assert.NoError(t, err)
+    claims := parsed.Claims.(jwt.MapClaims)
+    claims["exp"] = time.Now().Add(2 * time.Second).Unix()
+    shortLived, err := jwtKeys.sign(claims)
+    assert.NoError(t, err)
+    
+    resp, _ := openEventStream(t, server, shortLived, "")
+    assert.Equal(t, 200, resp.StatusCode)
+    
+    _, err = io.ReadAll(resp.Body)
+    assert.NoError(t, err)
+}
@@ -6,6 +6,7 @@ require (
     github.com/gin-contrib/cors v1.4.0
     github.com/gin-gonic/gin v1.9.1
     github.com/golang-jwt/jwt/v5 v5.0.0
+    github.com/jackc/pgx/v5 v5.4.3
     golang.org/x/crypto v0.14.0
     gorm.io/driver/postgres v1.5.3
     gorm.io/gorm v1.25.5
@@ -0,0 +1,58 @@
+package main
+
+import (
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Registro de auditoria de cada mudança de status de um pedido
+type StatusHistory struct {
+    ID              uint      `json:"id" gorm:"primaryKey"`
+    TravelRequestID uint      `json:"travel_request_id" gorm:"index"`
+    FromStatus      string    `json:"from_status"` // Vazio na criação do pedido
+    ToStatus        string    `json:"to_status"`
+    ActorID         uint      `json:"actor_id"`
+    Actor           *User     `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+    OnBehalfOfID    *uint     `json:"on_behalf_of_id"` // Aprovador ausente em nome de quem o substituto agiu
+    OnBehalfOf      *User     `json:"on_behalf_of,omitempty" gorm:"foreignKey:OnBehalfOfID"`
+    Reason          string    `json:"reason"`
+    CreatedAt       time.Time `json:"created_at"`
+}
+
+// recordStatusChange grava a transição na mesma transação da mudança de status
+func recordStatusChange(tx *gorm.DB, request TravelRequest, fromStatus string, actorID uint, reason string) error {
+    return recordStatusChangeOnBehalf(tx, request, fromStatus, actorID, nil, reason)
+}
+
+// recordStatusChangeOnBehalf grava a transição feita por um substituto,
+// registrando também o aprovador ausente
+func recordStatusChangeOnBehalf(tx *gorm.DB, request TravelRequest, fromStatus string, actorID uint, onBehalfOfID *uint, reason string) error {
+    return tx.Create(&StatusHistory{
+        TravelRequestID: request.ID,
+        FromStatus:      fromStatus,
+        ToStatus:        request.Status,
+        ActorID:         actorID,
+        OnBehalfOfID:    onBehalfOfID,
+        Reason:          reason,
+    }).Error
+}
+
+func getStatusHistoryHandler(c *gin.Context) {
+    request, err := findVisibleTravelRequest(c, c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
+        return
+    }
+
+    var history []StatusHistory
+    db.Preload("Actor").Preload("OnBehalfOf").Where("travel_request_id = ?", request.ID).Order("created_at ASC, id ASC").Find(&history)
+
+    if history == nil {
+        history = []StatusHistory{}
+    }
+
+    c.JSON(http.StatusOK, history)
+}
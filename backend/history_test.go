@@ -0,0 +1,56 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestStatusHistoryRecordsEveryTransition(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    adminToken := registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    
+    id := createTestTravelRequest(router, ownerToken, "Porto Alegre")
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    
+    performConditional(router, "PUT", path+"/status", adminToken, "*", UpdateStatusRequest{Status: StatusApproved, Reason: "Dentro do orçamento"})
+    performConditional(router, "DELETE", path, ownerToken, "*", CancelTravelRequest{Reason: "Evento adiado"})
+    
+    w := performRequest(router, "GET", path+"/history", ownerToken, nil)
+    assert.Equal(t, 200, w.Code)
+    
+    var history []StatusHistory
+    json.Unmarshal(w.Body.Bytes(), &history)
+    
+    assert.Len(t, history, 3)
+    assert.Equal(t, "", history[0].FromStatus)
+    assert.Equal(t, StatusRequested, history[0].ToStatus)
+    
+    assert.Equal(t, StatusRequested, history[1].FromStatus)
+    assert.Equal(t, StatusApproved, history[1].ToStatus)
+    assert.Equal(t, uint(1), history[1].ActorID)
+    assert.Equal(t, "Dentro do orçamento", history[1].Reason)
+    assert.Equal(t, "Admin", history[1].Actor.Name)
+    
+    assert.Equal(t, StatusCancelled, history[2].ToStatus)
+    assert.Equal(t, "Evento adiado", history[2].Reason)
+}
+
+func TestStatusHistoryRespectsVisibility(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    otherToken := registerAndLogin(router, "Other", "other@example.com", "")
+    
+    id := createTestTravelRequest(router, ownerToken, "Porto Alegre")
+    
+    w := performRequest(router, "GET", fmt.Sprintf("/api/travel-requests/%d/history", id), otherToken, nil)
+    assert.Equal(t, 404, w.Code)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+)
+
+// Canais controlados pelas preferências do usuário
+const (
+    ChannelInbox = "inbox"
+    ChannelEmail = "email"
+)
+
+// Eventos que geram notificação, na ordem exibida nas preferências
+var notificationEvents = []string{
+    EventApprovalRequested,
+    EventRequestCreated,
+    EventRequestStatusChanged,
+    EventRequestCancelled,
+    EventRequestReassigned,
+}
+
+// Preferência de entrega de um evento para um usuário. Sem registro, o
+// evento vai para a caixa de entrada e para o email.
+type NotificationPreference struct {
+    ID     uint   `json:"-" gorm:"primaryKey"`
+    UserID uint   `json:"-" gorm:"uniqueIndex:idx_notification_preferences_user_event"`
+    Event  string `json:"event" gorm:"uniqueIndex:idx_notification_preferences_user_event"`
+    Inbox  bool   `json:"inbox"`
+    Email  bool   `json:"email"`
+}
+
+type UpdateNotificationPreferences struct {
+    Locale      string                   `json:"locale" binding:"omitempty,oneof=pt-BR en"`
+    Preferences []NotificationPreference `json:"preferences"`
+}
+
+// recipientsWanting remove os destinatários que desligaram o canal para o evento
+func recipientsWanting(recipients []Recipient, eventType, channel string) ([]Recipient, error) {
+    if len(recipients) == 0 {
+        return recipients, nil
+    }
+
+    ids := make([]uint, 0, len(recipients))
+    for _, r := range recipients {
+        ids = append(ids, r.User.ID)
+    }
+    var preferences []NotificationPreference
+    if err := db.Where("user_id IN ? AND event = ?", ids, eventType).Find(&preferences).Error; err != nil {
+        return nil, err
+    }
+
+    disabled := map[uint]bool{}
+    for _, p := range preferences {
+        if (channel == ChannelInbox && !p.Inbox) || (channel == ChannelEmail && !p.Email) {
+            disabled[p.UserID] = true
+        }
+    }
+
+    wanted := make([]Recipient, 0, len(recipients))
+    for _, r := range recipients {
+        if !disabled[r.User.ID] {
+            wanted = append(wanted, r)
+        }
+    }
+    return wanted, nil
+}
+
+// effectivePreferences devolve a preferência de cada evento, preenchendo
+// com o padrão os que o usuário nunca alterou
+func effectivePreferences(userID uint) ([]NotificationPreference, error) {
+    var stored []NotificationPreference
+    if err := db.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+        return nil, err
+    }
+    byEvent := map[string]NotificationPreference{}
+    for _, p := range stored {
+        byEvent[p.Event] = p
+    }
+
+    preferences := make([]NotificationPreference, 0, len(notificationEvents))
+    for _, event := range notificationEvents {
+        preference, ok := byEvent[event]
+        if !ok {
+            preference = NotificationPreference{UserID: userID, Event: event, Inbox: true, Email: true}
+        }
+        preferences = append(preferences, preference)
+    }
+    return preferences, nil
+}
+
+// listNotificationsHandler lista a caixa de entrada do usuário, mais
+// recentes primeiro. Aceita ?unread=true, limit e page.
+func listNotificationsHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
+
+    limit := defaultPageLimit
+    if value := c.Query("limit"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 1 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro limit deve ser um número positivo"})
+            return
+        }
+        if parsed > maxPageLimit {
+            parsed = maxPageLimit
+        }
+        limit = parsed
+    }
+    page := 1
+    if value := c.Query("page"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 1 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro page deve ser um número positivo"})
+            return
+        }
+        page = parsed
+    }
+
+    query := db.Model(&Notification{}).Where("user_id = ?", userID)
+    if c.Query("unread") == "true" {
+        query = query.Where("read_at IS NULL")
+    }
+
+    var total int64
+    if err := query.Count(&total).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar notificações"})
+        return
+    }
+
+    notifications := []Notification{}
+    err := query.Order("created_at DESC, id DESC").Limit(limit).Offset((page - 1) * limit).Find(&notifications).Error
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar notificações"})
+        return
+    }
+
+    var unread int64
+    db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread)
+
+    c.JSON(http.StatusOK, gin.H{
+        "data":         notifications,
+        "unread_count": unread,
+        "total":        total,
+        "limit":        limit,
+        "page":         page,
+    })
+}
+
+func unreadNotificationsCountHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
+
+    var unread int64
+    if err := db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao contar notificações"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// markNotificationReadHandler marca uma notificação do próprio usuário como lida
+func markNotificationReadHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
+
+    var notification Notification
+    if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&notification).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Notificação não encontrada"})
+        return
+    }
+
+    if notification.ReadAt == nil {
+        now := time.Now()
+        notification.ReadAt = &now
+        if err := db.Model(&notification).Update("read_at", now).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar notificação"})
+            return
+        }
+    }
+
+    c.JSON(http.StatusOK, notification)
+}
+
+func markAllNotificationsReadHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
+
+    result := db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", time.Now())
+    if result.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar notificações"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
+
+func getNotificationPreferencesHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    preferences, err := effectivePreferences(user.ID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar preferências"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"locale": user.Locale, "preferences": preferences})
+}
+
+// updateNotificationPreferencesHandler grava as preferências enviadas; eventos
+// omitidos mantêm a configuração atual
+func updateNotificationPreferencesHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    var req UpdateNotificationPreferences
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    known := map[string]bool{}
+    for _, event := range notificationEvents {
+        known[event] = true
+    }
+    for _, p := range req.Preferences {
+        if !known[p.Event] {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Evento inválido: " + p.Event})
+            return
+        }
+    }
+
+    for _, p := range req.Preferences {
+        preference := NotificationPreference{UserID: user.ID, Event: p.Event}
+        if err := db.Where(preference).FirstOrInit(&preference).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar preferências"})
+            return
+        }
+        preference.Inbox = p.Inbox
+        preference.Email = p.Email
+        if err := db.Save(&preference).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar preferências"})
+            return
+        }
+    }
+
+    if req.Locale != "" && req.Locale != user.Locale {
+        if err := db.Model(&user).Update("locale", req.Locale).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar idioma"})
+            return
+        }
+    }
+
+    getNotificationPreferencesHandler(c)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+    
+    "github.com/stretchr/testify/assert"
+)
+
+type inboxResponse struct {
+    Data        []Notification `json:"data"`
+    UnreadCount int64          `json:"unread_count"`
+    Total       int64          `json:"total"`
+}
+
+func TestInboxListsAndMarksNotificationsRead(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    useNotifiers(t, inAppNotifier{})
+    
+    for i, destination := range []string{"Recife", "Natal"} {
+        requestID := createTestTravelRequest(router, tokens["requester"], destination)
+        w := performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", requestID, i+1), tokens["manager"], "*", nil)
+        assert.Equal(t, 200, w.Code)
+    }
+    flushOutbox(t)
+    
+    w := performRequest(router, "GET", "/api/notifications", tokens["requester"], nil)
+    assert.Equal(t, 200, w.Code)
+    var inbox inboxResponse
+    json.Unmarshal(w.Body.Bytes(), &inbox)
+    assert.Equal(t, int64(2), inbox.Total)
+    assert.Equal(t, int64(2), inbox.UnreadCount)
+    assert.Equal(t, EventRequestStatusChanged, inbox.Data[0].Event)
+    assert.Equal(t, uint(2), inbox.Data[0].TravelRequestID, "mais recentes primeiro")
+    
+    // O gestor recebeu os pedidos de aprovação na própria caixa
+    w = performRequest(router, "GET", "/api/notifications", tokens["manager"], nil)
+    var managerInbox inboxResponse
+    json.Unmarshal(w.Body.Bytes(), &managerInbox)
+    assert.Equal(t, int64(2), managerInbox.Total)
+    assert.Equal(t, EventApprovalRequested, managerInbox.Data[0].Event)
+    
+    readPath := fmt.Sprintf("/api/notifications/%d/read", inbox.Data[0].ID)
+    w = performRequest(router, "POST", readPath, tokens["manager"], nil)
+    assert.Equal(t, 404, w.Code, "notificação de outro usuário")
+    
+    w = performRequest(router, "POST", readPath, tokens["requester"], nil)
+    assert.Equal(t, 200, w.Code)
+    var read Notification
+    json.Unmarshal(w.Body.Bytes(), &read)
+    assert.NotNil(t, read.ReadAt)
+    
+    w = performRequest(router, "GET", "/api/notifications?unread=true&limit=1", tokens["requester"], nil)
+    json.Unmarshal(w.Body.Bytes(), &inbox)
+    assert.Len(t, inbox.Data, 1)
+    assert.Equal(t, int64(1), inbox.Total)
+    assert.Equal(t, uint(1), inbox.Data[0].TravelRequestID)
+    
+    w = performRequest(router, "POST", "/api/notifications/read-all", tokens["requester"], nil)
+    assert.Equal(t, 200, w.Code)
+    assert.JSONEq(t, `{"updated": 1}`, w.Body.String())
+    
+    w = performRequest(router, "GET", "/api/notifications/unread-count", tokens["requester"], nil)
+    assert.JSONEq(t, `{"unread_count": 0}`, w.Body.String())
+    
+    w = performRequest(router, "GET", "/api/notifications?limit=abc", tokens["requester"], nil)
+    assert.Equal(t, 400, w.Code)
+}
+
+func TestNotificationPreferencesControlChannels(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    server := startFakeSMTPServer(t)
+    useNotifiers(t, inAppNotifier{}, smtpNotifier{Addr: server.listener.Addr().String(), From: "viagens@example.com"})
+    
+    w := performRequest(router, "GET", "/api/notifications/preferences", tokens["requester"], nil)
+    assert.Equal(t, 200, w.Code)
+    var current struct {
+        Locale      string                   `json:"locale"`
+        Preferences []NotificationPreference `json:"preferences"`
+    }
+    json.Unmarshal(w.Body.Bytes(), &current)
+    assert.Equal(t, LocalePortuguese, current.Locale)
+    assert.Len(t, current.Preferences, len(notificationEvents))
+    for _, p := range current.Preferences {
+        assert.True(t, p.Inbox && p.Email, "sem preferência gravada, todos os canais ficam ligados")
+    }
+    
+    w = performRequest(router, "PUT", "/api/notifications/preferences", tokens["requester"], UpdateNotificationPreferences{
+        Preferences: []NotificationPreference{{Event: "request.unknown"}},
+    })
+    assert.Equal(t, 400, w.Code)
+    
+    w = performRequest(router, "PUT", "/api/notifications/preferences", tokens["requester"], UpdateNotificationPreferences{
+        Locale:      LocaleEnglish,
+        Preferences: []NotificationPreference{{Event: EventRequestStatusChanged, Inbox: false, Email: true}},
+    })
+    assert.Equal(t, 200, w.Code)
+    json.Unmarshal(w.Body.Bytes(), &current)
+    assert.Equal(t, LocaleEnglish, current.Locale)
+    
+    // O gestor só quer a caixa de entrada para pedidos de aprovação
+    w = performRequest(router, "PUT", "/api/notifications/preferences", tokens["manager"], UpdateNotificationPreferences{
+        Preferences: []NotificationPreference{{Event: EventApprovalRequested, Inbox: true, Email: false}},
+    })
+    assert.Equal(t, 200, w.Code)
+    
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    w = performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/1/approve", requestID), tokens["manager"], "*", nil)
+    assert.Equal(t, 200, w.Code)
+    flushOutbox(t)
+    
+    messages := server.Messages()
+    assert.Len(t, messages, 1)
+    assert.Equal(t, "requester@example.com", messages[0].To)
+    assert.Contains(t, messages[0].Data, `changed from "requested" to "approved"`)
+    
+    var notifications []Notification
+    db.Order("id").Find(&notifications)
+    assert.Len(t, notifications, 1)
+    assert.Equal(t, uint(2), notifications[0].UserID)
+    assert.Equal(t, EventApprovalRequested, notifications[0].Event)
+}
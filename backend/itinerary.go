@@ -0,0 +1,138 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// Meios de transporte de um trecho
+const (
+    TransportAir   = "air"
+    TransportTrain = "train"
+    TransportBus   = "bus"
+    TransportCar   = "car"
+)
+
+// Trecho do itinerário de um pedido. Os trechos são encadeados: cada um
+// parte de onde o anterior chegou, em ordem cronológica.
+type ItineraryLeg struct {
+    ID              uint      `json:"id" gorm:"primaryKey"`
+    TravelRequestID uint      `json:"-" gorm:"index"`
+    Position        int       `json:"position"`
+    Origin          string    `json:"origin"`
+    OriginID        string    `json:"origin_id,omitempty"` // Cidade no catálogo de destinos
+    Destination     string    `json:"destination"`
+    DestinationID   string    `json:"destination_id,omitempty"`
+    Date            time.Time `json:"date"`
+    TransportMode   string    `json:"transport_mode"`
+}
+
+type ItineraryLegInput struct {
+    Origin        string `json:"origin" binding:"required"`
+    Destination   string `json:"destination" binding:"required"`
+    Date          string `json:"date" binding:"required"` // YYYY-MM-DD
+    TransportMode string `json:"transport_mode" binding:"required,oneof=air train bus car"`
+}
+
+// planTrip define destino e datas do pedido. Sem trechos valem os campos
+// informados; com trechos, as datas vêm do itinerário e o destino principal,
+// se omitido ou fora do itinerário, é o do primeiro trecho. Lugares do
+// catálogo de destinos recebem o nome canônico.
+func planTrip(destination, departure, ret string, inputs []ItineraryLegInput) ([]ItineraryLeg, string, time.Time, time.Time, error) {
+    destination, _ = canonicalPlace(destination)
+    if len(inputs) == 0 {
+        if destination == "" {
+            return nil, "", time.Time{}, time.Time{}, fmt.Errorf("Informe o destino ou os trechos do itinerário")
+        }
+        departureDate, returnDate, err := parseTravelDates(departure, ret)
+        return nil, destination, departureDate, returnDate, err
+    }
+
+    legs, departureDate, returnDate, err := buildItinerary(inputs)
+    if err != nil {
+        return nil, "", time.Time{}, time.Time{}, err
+    }
+    for _, leg := range legs {
+        if strings.EqualFold(leg.Destination, destination) {
+            return legs, destination, departureDate, returnDate, nil
+        }
+    }
+    return legs, legs[0].Destination, departureDate, returnDate, nil
+}
+
+// buildItinerary valida os trechos e devolve as datas de ida (primeiro
+// trecho) e volta (último trecho) do pedido
+func buildItinerary(inputs []ItineraryLegInput) ([]ItineraryLeg, time.Time, time.Time, error) {
+    legs := make([]ItineraryLeg, 0, len(inputs))
+    for i, input := range inputs {
+        date, err := time.Parse("2006-01-02", input.Date)
+        if err != nil {
+            return nil, time.Time{}, time.Time{}, fmt.Errorf("Formato de data inválido no trecho %d (use YYYY-MM-DD)", i+1)
+        }
+
+        leg := ItineraryLeg{Position: i + 1, Date: date, TransportMode: input.TransportMode}
+        leg.Origin, leg.OriginID = canonicalPlace(input.Origin)
+        leg.Destination, leg.DestinationID = canonicalPlace(input.Destination)
+        if strings.EqualFold(leg.Origin, leg.Destination) {
+            return nil, time.Time{}, time.Time{}, fmt.Errorf("Trecho %d tem origem e destino iguais", i+1)
+        }
+
+        if i > 0 {
+            previous := legs[i-1]
+            if !strings.EqualFold(leg.Origin, previous.Destination) {
+                return nil, time.Time{}, time.Time{}, fmt.Errorf("Trecho %d deve partir de %s, destino do trecho anterior", i+1, previous.Destination)
+            }
+            if leg.Date.Before(previous.Date) {
+                return nil, time.Time{}, time.Time{}, fmt.Errorf("Trecho %d é anterior ao trecho %d", i+1, i)
+            }
+        }
+        legs = append(legs, leg)
+    }
+
+    return legs, legs[0].Date, legs[len(legs)-1].Date, nil
+}
+
+// replaceItinerary substitui os trechos gravados pelos do pedido
+func replaceItinerary(tx *gorm.DB, request *TravelRequest) error {
+    if err := tx.Where("travel_request_id = ?", request.ID).Delete(&ItineraryLeg{}).Error; err != nil {
+        return err
+    }
+    if len(request.Legs) == 0 {
+        return nil
+    }
+    for i := range request.Legs {
+        request.Legs[i].TravelRequestID = request.ID
+    }
+    return tx.Create(&request.Legs).Error
+}
+
+// itineraryInputs devolve os trechos no formato de entrada, para a edição
+func itineraryInputs(legs []ItineraryLeg) []ItineraryLegInput {
+    inputs := make([]ItineraryLegInput, 0, len(legs))
+    for _, leg := range legs {
+        inputs = append(inputs, ItineraryLegInput{
+            Origin:        leg.Origin,
+            Destination:   leg.Destination,
+            Date:          leg.Date.Format("2006-01-02"),
+            TransportMode: leg.TransportMode,
+        })
+    }
+    return inputs
+}
+
+// sameItinerary indica se os dois itinerários têm os mesmos trechos
+func sameItinerary(a, b []ItineraryLeg) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i].Origin != b[i].Origin || a[i].Destination != b[i].Destination ||
+            !a[i].Date.Equal(b[i].Date) || a[i].TransportMode != b[i].TransportMode {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func europeTrip() CreateTravelRequest {
+    return CreateTravelRequest{
+        Legs: []ItineraryLegInput{
+            {Origin: "São Paulo", Destination: "Lisboa", Date: "2025-10-01", TransportMode: TransportAir},
+            {Origin: "Lisboa", Destination: "Madrid", Date: "2025-10-04", TransportMode: TransportTrain},
+            {Origin: "Madrid", Destination: "São Paulo", Date: "2025-10-08", TransportMode: TransportAir},
+        },
+        International: true,
+    }
+}
+
+func TestCreateMultiLegItinerary(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    w := performRequest(router, "POST", "/api/travel-requests", token, europeTrip())
+    assert.Equal(t, 201, w.Code)
+    
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Equal(t, "Lisboa", created.Destination, "destino principal: primeiro trecho")
+    assert.Equal(t, "2025-10-01", created.DepartureDate.Format("2006-01-02"))
+    assert.Equal(t, "2025-10-08", created.ReturnDate.Format("2006-01-02"))
+    
+    w = performRequest(router, "GET", fmt.Sprintf("/api/travel-requests/%d", created.ID), token, nil)
+    var loaded TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &loaded)
+    assert.Len(t, loaded.Legs, 3)
+    assert.Equal(t, 2, loaded.Legs[1].Position)
+    assert.Equal(t, "Madrid", loaded.Legs[1].Destination)
+    assert.Equal(t, TransportTrain, loaded.Legs[1].TransportMode)
+    
+    // Trocar o itinerário recalcula as datas; o destino principal sai do itinerário
+    w = performMergePatch(router, fmt.Sprintf("/api/travel-requests/%d", created.ID), token, "*",
+        `{"legs": [{"origin": "São Paulo", "destination": "Santiago", "date": "2025-11-02", "transport_mode": "air"},
+                   {"origin": "Santiago", "destination": "São Paulo", "date": "2025-11-06", "transport_mode": "air"}]}`)
+    assert.Equal(t, 200, w.Code)
+    var updated TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &updated)
+    assert.Equal(t, "Santiago", updated.Destination)
+    assert.Equal(t, "2025-11-06", updated.ReturnDate.Format("2006-01-02"))
+    assert.Len(t, updated.Legs, 2)
+}
+
+func TestItineraryValidation(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    disconnected := europeTrip()
+    disconnected.Legs[2].Origin = "Paris"
+    w := performRequest(router, "POST", "/api/travel-requests", token, disconnected)
+    assert.Equal(t, 400, w.Code)
+    assert.Contains(t, w.Body.String(), "Trecho 3 deve partir de Madrid")
+    
+    backwards := europeTrip()
+    backwards.Legs[1].Date = "2025-09-30"
+    w = performRequest(router, "POST", "/api/travel-requests", token, backwards)
+    assert.Equal(t, 400, w.Code)
+    assert.Contains(t, w.Body.String(), "Trecho 2 é anterior ao trecho 1")
+    
+    unknownMode := europeTrip()
+    unknownMode.Legs[0].TransportMode = "teleport"
+    w = performRequest(router, "POST", "/api/travel-requests", token, unknownMode)
+    assert.Equal(t, 400, w.Code)
+    
+    loop := europeTrip()
+    loop.Legs = loop.Legs[:1]
+    loop.Legs[0].Destination = "são paulo"
+    w = performRequest(router, "POST", "/api/travel-requests", token, loop)
+    assert.Equal(t, 400, w.Code)
+    
+    w = performRequest(router, "POST", "/api/travel-requests", token, CreateTravelRequest{})
+    assert.Equal(t, 400, w.Code, "sem destino nem trechos")
+}
+
+func TestDestinationFilterMatchesAnyLeg(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    performRequest(router, "POST", "/api/travel-requests", token, europeTrip())
+    createTestTravelRequest(router, token, "Recife")
+    
+    for query, expected := range map[string]int64{"madrid": 1, "Lisboa": 1, "recife": 1, "paris": 0} {
+        w := performRequest(router, "GET", "/api/travel-requests?destination="+query, token, nil)
+        assert.Equal(t, 200, w.Code)
+        var response listResponse
+        json.Unmarshal(w.Body.Bytes(), &response)
+        assert.Equal(t, expected, response.Pagination.Total, query)
+    }
+}
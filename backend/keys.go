@@ -0,0 +1,251 @@
+package main
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/pem"
+    "fmt"
+    "log"
+    "math/big"
+    "net/http"
+    "os"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// Segredo HMAC de desenvolvimento, usado quando nenhuma chave é configurada
+const defaultJWTSecret = "travel-requests-jwt-secret-2024-secure-key"
+
+// Chave de assinatura/verificação identificada pelo "kid" do header do JWT
+type jwtKey struct {
+    ID        string
+    Method    jwt.SigningMethod
+    SignKey   interface{} // []byte (HMAC), *rsa.PrivateKey ou *ecdsa.PrivateKey
+    VerifyKey interface{} // []byte (HMAC), *rsa.PublicKey ou *ecdsa.PublicKey
+}
+
+// Conjunto de chaves: uma chave ativa para assinar e várias para verificar,
+// permitindo rotação sem derrubar tokens emitidos com a chave anterior
+type jwtKeySet struct {
+    signing *jwtKey
+    verify  map[string]*jwtKey
+}
+
+var jwtKeys = newHMACKeySet("default", []byte(defaultJWTSecret))
+
+func newHMACKeySet(kid string, secret []byte) *jwtKeySet {
+    key := &jwtKey{ID: kid, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}
+    return &jwtKeySet{signing: key, verify: map[string]*jwtKey{kid: key}}
+}
+
+// loadJWTKeys carrega as chaves da configuração e encerra o processo se inválidas
+func loadJWTKeys() {
+    keys, err := jwtKeySetFromEnv()
+    if err != nil {
+        log.Fatal("Falha ao carregar chaves JWT:", err)
+    }
+    jwtKeys = keys
+    print_status(fmt.Sprintf("Chaves JWT carregadas: %s (kid %s, %d chaves de verificação)",
+        keys.signing.Method.Alg(), keys.signing.ID, len(keys.verify)))
+}
+
+// jwtKeySetFromEnv monta o conjunto de chaves a partir de:
+//   JWT_ALGORITHM          HS256 (padrão), RS256 ou ES256
+//   JWT_KEY_ID             kid da chave de assinatura (padrão "default")
+//   JWT_SECRET[_FILE]      segredo HMAC para HS256 (obrigatório se ENV != development)
+//   JWT_PRIVATE_KEY_FILE   chave privada PEM para RS256/ES256
+//   JWT_VERIFICATION_KEYS  chaves adicionais "kid=arquivo,kid=arquivo" aceitas
+//                          apenas na verificação (chave pública PEM ou segredo HMAC)
+func jwtKeySetFromEnv() (*jwtKeySet, error) {
+    kid := getEnv("JWT_KEY_ID", "default")
+    algorithm := strings.ToUpper(getEnv("JWT_ALGORITHM", "HS256"))
+
+    var keys *jwtKeySet
+    switch algorithm {
+    case "HS256":
+        secret := []byte(getEnv("JWT_SECRET", ""))
+        if path := getEnv("JWT_SECRET_FILE", ""); path != "" {
+            data, err := os.ReadFile(path)
+            if err != nil {
+                return nil, err
+            }
+            secret = []byte(strings.TrimSpace(string(data)))
+        }
+        if len(secret) == 0 {
+            // O segredo padrão é público: só é aceito em desenvolvimento
+            if getEnv("ENV", "development") != "development" {
+                return nil, fmt.Errorf("JWT_SECRET ou JWT_SECRET_FILE é obrigatório fora de desenvolvimento")
+            }
+            print_status("ATENÇÃO: JWT_SECRET não configurado, usando o segredo público de desenvolvimento")
+            secret = []byte(defaultJWTSecret)
+        }
+        keys = newHMACKeySet(kid, secret)
+    case "RS256", "ES256":
+        path := getEnv("JWT_PRIVATE_KEY_FILE", "")
+        if path == "" {
+            return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE é obrigatório para %s", algorithm)
+        }
+        data, err := os.ReadFile(path)
+        if err != nil {
+            return nil, err
+        }
+        key, err := parsePrivateKey(kid, data)
+        if err != nil {
+            return nil, err
+        }
+        if key.Method.Alg() != algorithm {
+            return nil, fmt.Errorf("chave privada em %s não é compatível com %s", path, algorithm)
+        }
+        keys = &jwtKeySet{signing: key, verify: map[string]*jwtKey{kid: key}}
+    default:
+        return nil, fmt.Errorf("algoritmo JWT não suportado: %s", algorithm)
+    }
+
+    for _, entry := range strings.Split(getEnv("JWT_VERIFICATION_KEYS", ""), ",") {
+        entry = strings.TrimSpace(entry)
+        if entry == "" {
+            continue
+        }
+        parts := strings.SplitN(entry, "=", 2)
+        if len(parts) != 2 || parts[0] == "" {
+            return nil, fmt.Errorf("entrada inválida em JWT_VERIFICATION_KEYS: %s", entry)
+        }
+        if _, exists := keys.verify[parts[0]]; exists {
+            return nil, fmt.Errorf("kid duplicado em JWT_VERIFICATION_KEYS: %s", parts[0])
+        }
+        data, err := os.ReadFile(parts[1])
+        if err != nil {
+            return nil, err
+        }
+        key, err := parseVerificationKey(parts[0], data)
+        if err != nil {
+            return nil, err
+        }
+        keys.verify[key.ID] = key
+    }
+
+    return keys, nil
+}
+
+func parsePrivateKey(kid string, data []byte) (*jwtKey, error) {
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, fmt.Errorf("chave privada %s não está em formato PEM", kid)
+    }
+
+    var parsed interface{}
+    var err error
+    switch block.Type {
+    case "RSA PRIVATE KEY":
+        parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+    case "EC PRIVATE KEY":
+        parsed, err = x509.ParseECPrivateKey(block.Bytes)
+    default:
+        parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    switch key := parsed.(type) {
+    case *rsa.PrivateKey:
+        return &jwtKey{ID: kid, Method: jwt.SigningMethodRS256, SignKey: key, VerifyKey: &key.PublicKey}, nil
+    case *ecdsa.PrivateKey:
+        if key.Curve != elliptic.P256() {
+            return nil, fmt.Errorf("ES256 exige curva P-256 (kid %s)", kid)
+        }
+        return &jwtKey{ID: kid, Method: jwt.SigningMethodES256, SignKey: key, VerifyKey: &key.PublicKey}, nil
+    default:
+        return nil, fmt.Errorf("tipo de chave privada não suportado (kid %s)", kid)
+    }
+}
+
+// parseVerificationKey aceita chave pública PEM (RSA/EC) ou segredo HMAC em texto
+func parseVerificationKey(kid string, data []byte) (*jwtKey, error) {
+    block, _ := pem.Decode(data)
+    if block == nil {
+        secret := []byte(strings.TrimSpace(string(data)))
+        return &jwtKey{ID: kid, Method: jwt.SigningMethodHS256, VerifyKey: secret}, nil
+    }
+
+    parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+    if err != nil {
+        return nil, err
+    }
+
+    switch key := parsed.(type) {
+    case *rsa.PublicKey:
+        return &jwtKey{ID: kid, Method: jwt.SigningMethodRS256, VerifyKey: key}, nil
+    case *ecdsa.PublicKey:
+        if key.Curve != elliptic.P256() {
+            return nil, fmt.Errorf("ES256 exige curva P-256 (kid %s)", kid)
+        }
+        return &jwtKey{ID: kid, Method: jwt.SigningMethodES256, VerifyKey: key}, nil
+    default:
+        return nil, fmt.Errorf("tipo de chave pública não suportado (kid %s)", kid)
+    }
+}
+
+// sign assina as claims com a chave ativa, incluindo o "kid" no header
+func (k *jwtKeySet) sign(claims jwt.MapClaims) (string, error) {
+    token := jwt.NewWithClaims(k.signing.Method, claims)
+    token.Header["kid"] = k.signing.ID
+    return token.SignedString(k.signing.SignKey)
+}
+
+// parse valida o token com a chave indicada pelo "kid". Tokens sem "kid"
+// (emitidos antes da rotação de chaves) usam a chave de assinatura ativa.
+func (k *jwtKeySet) parse(tokenString string) (*jwt.Token, error) {
+    return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+        key := k.signing
+        if kid, ok := token.Header["kid"].(string); ok {
+            if key, ok = k.verify[kid]; !ok {
+                return nil, fmt.Errorf("kid desconhecido: %s", kid)
+            }
+        }
+        if token.Method.Alg() != key.Method.Alg() {
+            return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
+        }
+        return key.VerifyKey, nil
+    })
+}
+
+// jwks exporta as chaves públicas no formato JSON Web Key Set (RFC 7517).
+// Segredos HMAC nunca são publicados.
+func (k *jwtKeySet) jwks() []gin.H {
+    keys := []gin.H{}
+    for _, key := range k.verify {
+        switch public := key.VerifyKey.(type) {
+        case *rsa.PublicKey:
+            keys = append(keys, gin.H{
+                "kty": "RSA",
+                "use": "sig",
+                "alg": key.Method.Alg(),
+                "kid": key.ID,
+                "n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+                "e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+            })
+        case *ecdsa.PublicKey:
+            keys = append(keys, gin.H{
+                "kty": "EC",
+                "use": "sig",
+                "alg": key.Method.Alg(),
+                "kid": key.ID,
+                "crv": "P-256",
+                "x":   base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, 32))),
+                "y":   base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, 32))),
+            })
+        }
+    }
+    return keys
+}
+
+func jwksHandler(c *gin.Context) {
+    c.Header("Cache-Control", "public, max-age=300")
+    c.JSON(http.StatusOK, gin.H{"keys": jwtKeys.jwks()})
+}
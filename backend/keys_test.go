@@ -0,0 +1,137 @@
+package main
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/pem"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/stretchr/testify/assert"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+    path := filepath.Join(t.TempDir(), name)
+    data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+    if err := os.WriteFile(path, data, 0600); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestKeyRotationWithRS256(t *testing.T) {
+    oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+    newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+    oldPublic, _ := x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+    
+    t.Setenv("JWT_ALGORITHM", "RS256")
+    t.Setenv("JWT_KEY_ID", "2025-02")
+    t.Setenv("JWT_PRIVATE_KEY_FILE", writePEM(t, "new.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(newKey)))
+    t.Setenv("JWT_VERIFICATION_KEYS", "2025-01="+writePEM(t, "old.pub", "PUBLIC KEY", oldPublic))
+    
+    keys, err := jwtKeySetFromEnv()
+    assert.NoError(t, err)
+    
+    // Token emitido com a chave nova
+    signed, err := keys.sign(jwt.MapClaims{"user_id": 1})
+    assert.NoError(t, err)
+    token, err := keys.parse(signed)
+    assert.NoError(t, err)
+    assert.Equal(t, "2025-02", token.Header["kid"])
+    
+    // Token emitido antes da rotação continua válido
+    legacy := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"user_id": 1})
+    legacy.Header["kid"] = "2025-01"
+    legacySigned, _ := legacy.SignedString(oldKey)
+    _, err = keys.parse(legacySigned)
+    assert.NoError(t, err)
+    
+    // kid desconhecido é recusado
+    unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"user_id": 1})
+    unknown.Header["kid"] = "2024-12"
+    unknownSigned, _ := unknown.SignedString(oldKey)
+    _, err = keys.parse(unknownSigned)
+    assert.Error(t, err)
+    
+    jwks := keys.jwks()
+    assert.Len(t, jwks, 2)
+}
+
+func TestAlgorithmConfusionIsRejected(t *testing.T) {
+    key, _ := rsa.GenerateKey(rand.Reader, 2048)
+    public, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+    
+    t.Setenv("JWT_ALGORITHM", "RS256")
+    t.Setenv("JWT_PRIVATE_KEY_FILE", writePEM(t, "key.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)))
+    
+    keys, err := jwtKeySetFromEnv()
+    assert.NoError(t, err)
+    
+    // HS256 assinado com a chave pública não pode ser aceito
+    forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1})
+    forged.Header["kid"] = "default"
+    forgedSigned, _ := forged.SignedString(public)
+    _, err = keys.parse(forgedSigned)
+    assert.Error(t, err)
+}
+
+func TestES256TokensAndJWKSEndpoint(t *testing.T) {
+    key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    der, _ := x509.MarshalECPrivateKey(key)
+    
+    t.Setenv("JWT_ALGORITHM", "ES256")
+    t.Setenv("JWT_KEY_ID", "ec-1")
+    t.Setenv("JWT_PRIVATE_KEY_FILE", writePEM(t, "ec.pem", "EC PRIVATE KEY", der))
+    
+    keys, err := jwtKeySetFromEnv()
+    assert.NoError(t, err)
+    
+    previous := jwtKeys
+    jwtKeys = keys
+    defer func() { jwtKeys = previous }()
+    
+    setupTestDB()
+    router := setupTestRouter()
+    
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    w := performRequest(router, "GET", "/api/travel-requests", token, nil)
+    assert.Equal(t, 200, w.Code)
+    
+    w = performRequest(router, "GET", "/.well-known/jwks.json", "", nil)
+    assert.Equal(t, 200, w.Code)
+    assert.Contains(t, w.Body.String(), `"kid":"ec-1"`)
+    assert.Contains(t, w.Body.String(), `"crv":"P-256"`)
+}
+
+func TestInvalidKeyConfiguration(t *testing.T) {
+    t.Setenv("JWT_ALGORITHM", "RS256")
+    _, err := jwtKeySetFromEnv()
+    assert.Error(t, err)
+    
+    t.Setenv("JWT_ALGORITHM", "none")
+    _, err = jwtKeySetFromEnv()
+    assert.Error(t, err)
+}
+
+func TestDefaultSecretOnlyInDevelopment(t *testing.T) {
+    t.Setenv("JWT_SECRET", "")
+    t.Setenv("ENV", "production")
+    _, err := jwtKeySetFromEnv()
+    assert.Error(t, err)
+    
+    t.Setenv("JWT_SECRET", "segredo-de-producao")
+    keys, err := jwtKeySetFromEnv()
+    assert.NoError(t, err)
+    assert.Equal(t, []byte("segredo-de-producao"), keys.signing.SignKey)
+    
+    t.Setenv("JWT_SECRET", "")
+    t.Setenv("ENV", "development")
+    keys, err = jwtKeySetFromEnv()
+    assert.NoError(t, err)
+    assert.Equal(t, []byte(defaultJWTSecret), keys.signing.SignKey)
+}
@@ -17,28 +17,40 @@ import (
 )
 
 var db *gorm.DB
-var jwtSecret = []byte("travel-requests-jwt-secret-2024-secure-key")
 
 // Models
 type User struct {
-    ID        uint      `json:"id" gorm:"primaryKey"`
-    Name      string    `json:"name"`
-    Email     string    `json:"email" gorm:"uniqueIndex"`
-    Password  string    `json:"-"`
-    CreatedAt time.Time `json:"created_at"`
+    ID         uint      `json:"id" gorm:"primaryKey"`
+    Name       string    `json:"name"`
+    Email      string    `json:"email" gorm:"uniqueIndex"`
+    Password   string    `json:"-"`
+    Role       string    `json:"role" gorm:"default:'requester'"`
+    Department string    `json:"department"` // Equipe; aprovadores veem pedidos da própria equipe
+    ManagerID  *uint     `json:"manager_id"` // Gestor direto, primeira etapa da cadeia de aprovação
+    Locale     string    `json:"locale" gorm:"default:'pt-BR'"` // Idioma das notificações (pt-BR ou en)
+    CreatedAt  time.Time `json:"created_at"`
 }
 
 type TravelRequest struct {
     ID            uint      `json:"id" gorm:"primaryKey"`
-    RequesterName string    `json:"requester_name"`
-    Destination   string    `json:"destination"`
+    RequesterName string    `json:"requester_name"` // Nome do viajante, para exibição
+    TravelerID    uint      `json:"traveler_id" gorm:"index"` // Quem viaja: define gestor, equipe e conflitos de agenda
+    Destination   string    `json:"destination"` // Destino principal; com itinerário, detalhado em Legs
+    DestinationID string    `json:"destination_id,omitempty" gorm:"index"` // Cidade no catálogo de destinos; vazio se fora dele
     DepartureDate time.Time `json:"departure_date"`
     ReturnDate    time.Time `json:"return_date"`
     Status        string    `json:"status" gorm:"default:'solicitado'"`
-    UserID        uint      `json:"user_id"`        // Usuário que pode ver o pedido
+    Version       int       `json:"version" gorm:"not null;default:1"` // Controle de concorrência otimista (ETag)
+    EstimatedCost int64     `json:"estimated_cost"` // Custo estimado em centavos
+    International bool      `json:"international"`
+    CostCenter    string    `json:"cost_center" gorm:"index"` // Centro de custo cujo orçamento o pedido consome
+    UserID        uint      `json:"user_id"`        // Responsável pelo pedido (quem reservou, salvo reatribuição)
     CreatedByID   uint      `json:"created_by_id"`  // Usuário que criou (NÃO pode alterar)
     CreatedAt     time.Time `json:"created_at"`
     UpdatedAt     time.Time `json:"updated_at"`
+    CostItems     []CostItem `json:"cost_items,omitempty" gorm:"foreignKey:TravelRequestID"` // Compõem o EstimatedCost
+    Legs          []ItineraryLeg `json:"legs,omitempty" gorm:"foreignKey:TravelRequestID"` // Itinerário em ordem
+    PolicyWarnings []PolicyViolation `json:"policy_warnings,omitempty" gorm:"serializer:json"` // Alertas da política de viagens, exibidos aos aprovadores
 }
 
 // Request DTOs
@@ -46,6 +58,7 @@ type RegisterRequest struct {
     Name     string `json:"name" binding:"required"`
     Email    string `json:"email" binding:"required,email"`
     Password string `json:"password" binding:"required,min=6"`
+    Locale   string `json:"locale" binding:"omitempty,oneof=pt-BR en"`
 }
 
 type LoginRequest struct {
@@ -54,21 +67,31 @@ type LoginRequest struct {
 }
 
 type CreateTravelRequest struct {
-    RequesterName string `json:"requester_name" binding:"required"`
-    Destination   string `json:"destination" binding:"required"`
-    DepartureDate string `json:"departure_date" binding:"required"`
-    ReturnDate    string `json:"return_date" binding:"required"`
+    TravelerID    uint   `json:"traveler_id"` // Padrão: o próprio usuário; outra pessoa exige delegação
+    Destination   string `json:"destination" binding:"required_without=Legs"`
+    DepartureDate string `json:"departure_date" binding:"required_without=Legs"` // Com trechos, vem do itinerário
+    ReturnDate    string `json:"return_date" binding:"required_without=Legs"`
+    Legs          []ItineraryLegInput `json:"legs" binding:"omitempty,dive"`
+    EstimatedCost int64  `json:"estimated_cost" binding:"min=0"` // Centavos; calculado quando há itens de custo
+    CostItems     []CostItemInput `json:"cost_items" binding:"omitempty,dive"`
+    International bool   `json:"international"`
+    CostCenter    string `json:"cost_center"` // Padrão: equipe do solicitante
+    Draft         bool   `json:"draft"` // Cria como rascunho, a ser enviado depois
 }
 
 type UpdateStatusRequest struct {
     Status string `json:"status" binding:"required"`
+    Reason string `json:"reason"` // Motivo opcional, registrado no histórico
+}
+
+type CancelTravelRequest struct {
+    Reason string `json:"reason"`
 }
 
 func main() {
-    print_status("Iniciando Travel Requests Backend...")
-    
-    setupDatabase()
-    setupRoutes()
+    if err := runCLI(os.Args[1:]); err != nil {
+        log.Fatal(err)
+    }
 }
 
 func print_status(msg string) {
@@ -76,14 +99,31 @@ func print_status(msg string) {
 }
 
 func setupDatabase() {
-    print_status("Conectando ao banco de dados...")
+    connectDatabase()
+
+    // Réplicas iniciando juntas são serializadas pelo advisory lock das migrações
+    if getEnv("DB_AUTO_MIGRATE", "true") == "true" {
+        if err := migrateUp(db, embeddedMigrations); err != nil {
+            log.Fatal("Falha nas migrations:", err)
+        }
+    }
     
-    dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
+    print_status("Banco de dados conectado e migrado com sucesso!")
+}
+
+func databaseDSN() string {
+    return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
         getEnv("DB_HOST", "postgres"),
         getEnv("DB_USER", "postgres"),
         getEnv("DB_PASSWORD", "postgres"),
         getEnv("DB_NAME", "travel_requests"),
         getEnv("DB_PORT", "5432"))
+}
+
+func connectDatabase() {
+    print_status("Conectando ao banco de dados...")
+    
+    dsn := databaseDSN()
 
     var err error
     for i := 0; i < 10; i++ {
@@ -98,13 +138,6 @@ func setupDatabase() {
     if err != nil {
         log.Fatal("Falha ao conectar com o banco após 10 tentativas:", err)
     }
-
-    // Auto migrate
-    if err := db.AutoMigrate(&User{}, &TravelRequest{}); err != nil {
-        log.Fatal("Falha nas migrations:", err)
-    }
-    
-    print_status("Banco de dados conectado e migrado com sucesso!")
 }
 
 func setupRoutes() {
@@ -112,15 +145,25 @@ func setupRoutes() {
         gin.SetMode(gin.ReleaseMode)
     }
 
-    r := gin.Default()
+    r := gin.New()
+    r.Use(gin.LoggerWithFormatter(accessLogFormatter), gin.Recovery())
 
     r.Use(cors.New(cors.Config{
         AllowOrigins:     []string{"http://localhost:3000", "http://frontend", "http://frontend:80", "*"},
-        AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-        AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+        AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+        AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", "If-None-Match"},
+        ExposeHeaders:    []string{"Link", "X-Total-Count", "ETag"},
         AllowCredentials: true,
     }))
 
+    registerRoutes(r)
+
+    port := getEnv("PORT", "8080")
+    print_status(fmt.Sprintf("Servidor iniciando na porta %s", port))
+    log.Fatal(r.Run(":" + port))
+}
+
+func registerRoutes(r *gin.Engine) {
     r.GET("/health", func(c *gin.Context) {
         c.JSON(200, gin.H{
             "status": "ok",
@@ -129,10 +172,14 @@ func setupRoutes() {
         })
     })
 
+    r.GET("/.well-known/jwks.json", jwksHandler)
+
     auth := r.Group("/api/auth")
     {
         auth.POST("/register", registerHandler)
         auth.POST("/login", loginHandler)
+        auth.POST("/refresh", refreshTokenHandler)
+        auth.POST("/logout", authMiddleware(), logoutHandler)
     }
 
     api := r.Group("/api/travel-requests")
@@ -140,14 +187,105 @@ func setupRoutes() {
     {
         api.POST("", createTravelRequestHandler)
         api.GET("", listTravelRequestsHandler)
+        api.GET("/conflicts", requireRole(RoleAdmin), listTripConflictsHandler)
         api.GET("/:id", getTravelRequestHandler)
+        api.PUT("/:id", updateTravelRequestHandler)
+        api.PATCH("/:id", patchTravelRequestHandler)
         api.PUT("/:id/status", updateStatusHandler)
         api.DELETE("/:id", cancelTravelRequestHandler)
+        api.GET("/:id/history", getStatusHistoryHandler)
+        api.GET("/:id/approvals", listApprovalStepsHandler)
+        api.POST("/:id/approvals/:step_id/approve", approveStepHandler)
+        api.POST("/:id/approvals/:step_id/reject", rejectStepHandler)
+        api.GET("/:id/shares", listSharesHandler)
+        api.POST("/:id/shares", shareTravelRequestHandler)
+        api.DELETE("/:id/shares/:user_id", unshareTravelRequestHandler)
     }
 
-    port := getEnv("PORT", "8080")
-    print_status(fmt.Sprintf("Servidor iniciando na porta %s", port))
-    log.Fatal(r.Run(":" + port))
+    // Stream SSE dos eventos de pedidos visíveis ao usuário
+    r.GET("/api/events", tokenFromQuery(), authMiddleware(), streamEventsHandler)
+
+    approvals := r.Group("/api/approvals")
+    approvals.Use(authMiddleware())
+    {
+        approvals.GET("/pending", pendingApprovalsHandler)
+    }
+
+    notifications := r.Group("/api/notifications")
+    notifications.Use(authMiddleware())
+    {
+        notifications.GET("", listNotificationsHandler)
+        notifications.GET("/unread-count", unreadNotificationsCountHandler)
+        notifications.POST("/read-all", markAllNotificationsReadHandler)
+        notifications.POST("/:id/read", markNotificationReadHandler)
+        notifications.GET("/preferences", getNotificationPreferencesHandler)
+        notifications.PUT("/preferences", updateNotificationPreferencesHandler)
+    }
+
+    // Catálogo de destinos (autocomplete)
+    destinations := r.Group("/api/destinations")
+    destinations.Use(authMiddleware())
+    {
+        destinations.GET("", searchDestinationsHandler)
+        destinations.GET("/:id", getDestinationHandler)
+    }
+
+    // Substitutos de aprovadores ausentes
+    approvalDelegations := r.Group("/api/approval-delegations")
+    approvalDelegations.Use(authMiddleware())
+    {
+        approvalDelegations.GET("", listApprovalDelegationsHandler)
+        approvalDelegations.POST("", createApprovalDelegationHandler)
+        approvalDelegations.DELETE("/:id", deleteApprovalDelegationHandler)
+    }
+
+    // Quem pode reservar viagens em nome de quem
+    bookingDelegations := r.Group("/api/booking-delegations")
+    bookingDelegations.Use(authMiddleware())
+    {
+        bookingDelegations.GET("", listBookingDelegationsHandler)
+        bookingDelegations.POST("", createBookingDelegationHandler)
+        bookingDelegations.DELETE("/:id", deleteBookingDelegationHandler)
+    }
+
+    budgets := r.Group("/api/budgets")
+    budgets.Use(authMiddleware())
+    {
+        budgets.GET("", requireRole(RoleFinance, RoleDirector), listBudgetsHandler)
+        budgets.GET("/summary", requireRole(RoleFinance, RoleDirector, RoleManager), budgetSummaryHandler)
+        budgets.GET("/:id", requireRole(RoleFinance, RoleDirector), getBudgetHandler)
+        budgets.POST("", requireRole(RoleFinance), createBudgetHandler)
+        budgets.PUT("/:id", requireRole(RoleFinance), updateBudgetHandler)
+        budgets.DELETE("/:id", requireRole(RoleFinance), deleteBudgetHandler)
+    }
+
+    users := r.Group("/api/users")
+    users.Use(authMiddleware(), requireRole(RoleAdmin))
+    {
+        users.GET("", listUsersHandler)
+        users.PUT("/:id/role", updateUserRoleHandler)
+        users.PUT("/:id/department", updateUserDepartmentHandler)
+        users.PUT("/:id/manager", updateUserManagerHandler)
+    }
+
+    outbox := r.Group("/api/outbox")
+    outbox.Use(authMiddleware(), requireRole(RoleAdmin))
+    {
+        outbox.GET("", listOutboxEventsHandler)
+        outbox.POST("/:id/retry", retryOutboxEventHandler)
+    }
+
+    webhooks := r.Group("/api/webhooks")
+    webhooks.Use(authMiddleware(), requireRole(RoleAdmin))
+    {
+        webhooks.GET("", listWebhookSubscriptionsHandler)
+        webhooks.POST("", createWebhookSubscriptionHandler)
+        webhooks.GET("/:id", getWebhookSubscriptionHandler)
+        webhooks.PUT("/:id", updateWebhookSubscriptionHandler)
+        webhooks.DELETE("/:id", deleteWebhookSubscriptionHandler)
+        webhooks.GET("/:id/deliveries", listWebhookDeliveriesHandler)
+        webhooks.POST("/:id/deliveries/:delivery_id/redeliver", redeliverWebhookHandler)
+    }
 }
 
 func getEnv(key, defaultValue string) string {
@@ -167,12 +305,7 @@ func authMiddleware() gin.HandlerFunc {
         }
 
         tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-        token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-            if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-                return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
-            }
-            return jwtSecret, nil
-        })
+        token, err := jwtKeys.parse(tokenString)
 
         if err != nil || !token.Valid {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
@@ -183,6 +316,22 @@ func authMiddleware() gin.HandlerFunc {
         if claims, ok := token.Claims.(jwt.MapClaims); ok {
             userID := uint(claims["user_id"].(float64))
             c.Set("user_id", userID)
+
+            // Tokens emitidos antes dos papéis existirem não têm a claim "role"
+            role, _ := claims["role"].(string)
+            if role == "" {
+                role = RoleRequester
+            }
+            c.Set("user_role", role)
+
+            // Sessão encerrada por logout ou reutilização de refresh token
+            sessionID, _ := claims["sid"].(string)
+            if sessionID == "" || isTokenFamilyRevoked(sessionID) {
+                c.JSON(http.StatusUnauthorized, gin.H{"error": "Token revogado"})
+                c.Abort()
+                return
+            }
+            c.Set("session_id", sessionID)
         } else {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "Claims inválidas"})
             c.Abort()
@@ -216,6 +365,11 @@ func registerHandler(c *gin.Context) {
         Name:     req.Name,
         Email:    req.Email,
         Password: string(hashedPassword),
+        Role:     RoleRequester,
+        Locale:   LocalePortuguese,
+    }
+    if req.Locale != "" {
+        user.Locale = req.Locale
     }
 
     if err := db.Create(&user).Error; err != nil {
@@ -230,6 +384,7 @@ func registerHandler(c *gin.Context) {
             "id":    user.ID,
             "name":  user.Name,
             "email": user.Email,
+            "role":  user.Role,
         },
     })
 }
@@ -252,32 +407,29 @@ func loginHandler(c *gin.Context) {
         return
     }
 
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-        "user_id": user.ID,
-        "email":   user.Email,
-        "exp":     time.Now().Add(time.Hour * 24).Unix(),
-        "iat":     time.Now().Unix(),
-    })
+    // Cada login inicia uma nova sessão (família de refresh tokens)
+    familyID, err := randomToken(16)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar token"})
+        return
+    }
 
-    tokenString, err := token.SignedString(jwtSecret)
+    pair, err := issueTokenPair(db, user, familyID)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar token"})
         return
     }
 
     print_status(fmt.Sprintf("Login realizado: %s", user.Email))
-    c.JSON(http.StatusOK, gin.H{
-        "token": tokenString,
-        "user": gin.H{
-            "id":    user.ID,
-            "name":  user.Name,
-            "email": user.Email,
-        },
-    })
+    c.JSON(http.StatusOK, tokenResponse(pair, user))
 }
 
 func createTravelRequestHandler(c *gin.Context) {
-    userID, _ := c.Get("user_id")
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
     
     var req CreateTravelRequest
     if err := c.ShouldBindJSON(&req); err != nil {
@@ -285,56 +437,146 @@ func createTravelRequestHandler(c *gin.Context) {
         return
     }
 
-    departureDate, err := time.Parse("2006-01-02", req.DepartureDate)
+    traveler, err := resolveTraveler(user, req.TravelerID)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data de ida inválido (use YYYY-MM-DD)"})
+        respondTravelerError(c, err)
         return
     }
 
-    returnDate, err := time.Parse("2006-01-02", req.ReturnDate)
+    legs, destination, departureDate, returnDate, err := planTrip(req.Destination, req.DepartureDate, req.ReturnDate, req.Legs)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data de volta inválido (use YYYY-MM-DD)"})
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
 
-    if returnDate.Before(departureDate) {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Data de volta deve ser posterior à data de ida"})
+    costItems, estimatedCost, err := buildCostItems(req.CostItems, req.EstimatedCost)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    status := StatusRequested
+    if req.Draft {
+        status = StatusDraft
+    }
+
+    destination, destinationID := canonicalPlace(destination)
+
+    costCenter := strings.TrimSpace(req.CostCenter)
+    if costCenter == "" {
+        costCenter = traveler.Department
+    } else if err := validateCostCenter(user, traveler, costCenter); err != nil {
+        respondCostCenterError(c, err)
         return
     }
 
-    userIDValue := userID.(uint)
+    userIDValue := user.ID
     travelRequest := TravelRequest{
-        RequesterName: req.RequesterName,
-        Destination:   req.Destination,
+        RequesterName: traveler.Name,
+        TravelerID:    traveler.ID,
+        Destination:   destination,
+        DestinationID: destinationID,
         DepartureDate: departureDate,
         ReturnDate:    returnDate,
-        Status:        "solicitado",
+        Legs:          legs,
+        Status:        status,
+        Version:       1,
+        EstimatedCost: estimatedCost,
+        CostItems:     costItems,
+        International: tripInternational(destinationID, legs, req.International),
+        CostCenter:    costCenter,
         UserID:        userIDValue,     // Usuário que pode ver
         CreatedByID:   userIDValue,     // Usuário que criou (não pode alterar status)
     }
 
-    if err := db.Create(&travelRequest).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar pedido de viagem"})
+    if err := applyTravelPolicy(&travelRequest); err != nil {
+        respondPolicyViolation(c, err)
         return
     }
 
-    print_status(fmt.Sprintf("Novo pedido criado: %s para %s", req.RequesterName, req.Destination))
-    log.Printf("📧 [NOTIFICATION] Pedido de viagem criado para %s - Destino: %s", 
-        travelRequest.RequesterName, travelRequest.Destination)
+    err = db.Transaction(func(tx *gorm.DB) error {
+        if err := checkTripOverlaps(tx, &travelRequest); err != nil {
+            return err
+        }
+        if err := tx.Create(&travelRequest).Error; err != nil {
+            return err
+        }
+        if err := recordStatusChange(tx, travelRequest, "", userIDValue, ""); err != nil {
+            return err
+        }
+        err := enqueueEvent(tx, TravelRequestEvent{
+            Type:    EventRequestCreated,
+            Request: travelRequest,
+            ActorID: userIDValue,
+        })
+        if err != nil {
+            return err
+        }
+        return syncApprovalChain(tx, travelRequest, userIDValue)
+    })
+    if err != nil {
+        if !respondTripOverlap(c, err) {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar pedido de viagem"})
+        }
+        return
+    }
+
+    setETag(c, travelRequest)
+    print_status(fmt.Sprintf("Novo pedido criado: %s para %s", travelRequest.RequesterName, travelRequest.Destination))
 
     c.JSON(http.StatusCreated, travelRequest)
 }
 
+// parseTravelDates valida as datas de ida e volta (YYYY-MM-DD)
+func parseTravelDates(departure, ret string) (time.Time, time.Time, error) {
+    departureDate, err := time.Parse("2006-01-02", departure)
+    if err != nil {
+        return time.Time{}, time.Time{}, fmt.Errorf("Formato de data de ida inválido (use YYYY-MM-DD)")
+    }
+
+    returnDate, err := time.Parse("2006-01-02", ret)
+    if err != nil {
+        return time.Time{}, time.Time{}, fmt.Errorf("Formato de data de volta inválido (use YYYY-MM-DD)")
+    }
+
+    if returnDate.Before(departureDate) {
+        return time.Time{}, time.Time{}, fmt.Errorf("Data de volta deve ser posterior à data de ida")
+    }
+
+    return departureDate, returnDate, nil
+}
+
 func listTravelRequestsHandler(c *gin.Context) {
     var requests []TravelRequest
-    query := db.Model(&TravelRequest{})
+    query := visibleTravelRequests(c)
 
     // Filtros implementados
     if status := c.Query("status"); status != "" {
         query = query.Where("status = ?", status)
     }
+    if travelerID := c.Query("traveler_id"); travelerID != "" {
+        query = query.Where("travel_requests.traveler_id = ?", travelerID)
+    }
     if destination := c.Query("destination"); destination != "" {
-        query = query.Where("destination ILIKE ?", "%"+destination+"%")
+        // Casa com o destino principal ou com o destino de qualquer trecho. Termos
+        // do catálogo ("SP", "Sao Paulo") casam também pelo ID da cidade.
+        pattern := "%" + strings.ToLower(destination) + "%"
+        _, destinationID := canonicalPlace(destination)
+        if destinationID == "" {
+            query = query.Where("LOWER(travel_requests.destination) LIKE ? OR EXISTS (?)", pattern,
+                db.Table("itinerary_legs").Select("1").
+                    Where("itinerary_legs.travel_request_id = travel_requests.id AND LOWER(itinerary_legs.destination) LIKE ?", pattern))
+        } else {
+            query = query.Where("LOWER(travel_requests.destination) LIKE ? OR travel_requests.destination_id = ? OR EXISTS (?)", pattern, destinationID,
+                db.Table("itinerary_legs").Select("1").
+                    Where("itinerary_legs.travel_request_id = travel_requests.id AND (LOWER(itinerary_legs.destination) LIKE ? OR itinerary_legs.destination_id = ?)", pattern, destinationID))
+        }
+    }
+    if destinationID := c.Query("destination_id"); destinationID != "" {
+        destinationID = strings.ToUpper(destinationID)
+        query = query.Where("travel_requests.destination_id = ? OR EXISTS (?)", destinationID,
+            db.Table("itinerary_legs").Select("1").
+                Where("itinerary_legs.travel_request_id = travel_requests.id AND itinerary_legs.destination_id = ?", destinationID))
     }
     
     // NOVO: Filtros por período
@@ -361,24 +603,59 @@ func listTravelRequestsHandler(c *gin.Context) {
         }
     }
 
-    query.Order("created_at DESC").Find(&requests)
+    params, err := parsePageParams(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Sessão permite reutilizar os filtros na contagem e na busca
+    query = query.Session(&gorm.Session{})
+
+    var total int64
+    if err := query.Count(&total).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao listar pedidos de viagem"})
+        return
+    }
+
+    paged, err := paginate(query, params)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    withTravelRequestDetails(paged).Find(&requests)
     
     if requests == nil {
         requests = []TravelRequest{}
     }
+
+    requests, meta := buildPageMeta(c, requests, total, params)
+    if meta.Next != "" {
+        c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", meta.Next))
+    }
+    c.Header("X-Total-Count", fmt.Sprint(total))
     
-    c.JSON(http.StatusOK, requests)
+    c.JSON(http.StatusOK, gin.H{
+        "data":       requests,
+        "pagination": meta,
+    })
 }
 
 func getTravelRequestHandler(c *gin.Context) {
     id := c.Param("id")
 
-    var request TravelRequest
-    if err := db.Where("id = ?", id).First(&request).Error; err != nil {
+    request, err := findVisibleTravelRequest(c, id)
+    if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
         return
     }
 
+    setETag(c, request)
+    if header := c.GetHeader("If-None-Match"); header != "" && etagMatches(header, request) {
+        c.Status(http.StatusNotModified)
+        return
+    }
+
     c.JSON(http.StatusOK, request)
 }
 
@@ -386,17 +663,13 @@ func updateStatusHandler(c *gin.Context) {
     userID, _ := c.Get("user_id")
     id := c.Param("id")
 
-    var request TravelRequest
-    if err := db.Where("id = ?", id).First(&request).Error; err != nil {
+    request, err := findVisibleTravelRequest(c, id)
+    if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
         return
     }
 
-    // REGRA DE NEGÓCIO: Usuário que criou o pedido NÃO pode alterar o status
-    if request.CreatedByID == userID.(uint) {
-        c.JSON(http.StatusForbidden, gin.H{
-            "error": "Você não pode alterar o status de um pedido que você mesmo criou. Outro usuário deve fazer essa alteração.",
-        })
+    if !checkIfMatch(c, request) {
         return
     }
 
@@ -406,59 +679,145 @@ func updateStatusHandler(c *gin.Context) {
         return
     }
 
-    validStatuses := map[string]bool{
-        "solicitado": true,
-        "aprovado":   true,
-        "cancelado":  true,
-    }
-    
     if !validStatuses[req.Status] {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Status inválido. Use: solicitado, aprovado ou cancelado"})
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Status inválido. Use: rascunho, solicitado, aprovado, rejeitado, em_viagem, concluido ou cancelado"})
+        return
+    }
+
+    // Com cadeia de aprovação, aprovar/rejeitar decide a etapa atual; o pedido
+    // só chega a "aprovado" depois que todas as etapas forem aprovadas
+    if req.Status == StatusApproved || req.Status == StatusRejected {
+        if step, ok := currentApprovalStep(request.ID); ok {
+            decideApprovalStep(c, request, step, req.Status == StatusApproved, req.Reason)
+            return
+        }
+    }
+
+    // Transições e papéis permitidos são definidos na máquina de estados
+    onBehalfOfID, ok := authorizeTransition(c, request, req.Status)
+    if !ok {
         return
     }
 
     oldStatus := request.Status
     request.Status = req.Status
     
-    if err := db.Save(&request).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar status"})
+    err = db.Transaction(func(tx *gorm.DB) error {
+        if request.Status == StatusApproved {
+            if err := applyTravelPolicy(&request); err != nil {
+                return err
+            }
+            if err := checkTripOverlaps(tx, &request); err != nil {
+                return err
+            }
+            escalated, err := enforceBudget(tx, request)
+            if err != nil {
+                return err
+            }
+            if escalated {
+                // Continua aguardando aprovação, agora na etapa de orçamento
+                request.Status = oldStatus
+                if err := saveTravelRequest(tx, &request); err != nil {
+                    return err
+                }
+                return enqueueApprovalRequested(tx, request, userID.(uint))
+            }
+        }
+        if err := saveTravelRequest(tx, &request); err != nil {
+            return err
+        }
+        if err := recordStatusChangeOnBehalf(tx, request, oldStatus, userID.(uint), onBehalfOfID, req.Reason); err != nil {
+            return err
+        }
+        err := enqueueEvent(tx, TravelRequestEvent{
+            Type:       statusEventType(request.Status),
+            Request:    request,
+            FromStatus: oldStatus,
+            ActorID:    userID.(uint),
+            Reason:     req.Reason,
+        })
+        if err != nil {
+            return err
+        }
+        return syncApprovalChain(tx, request, userID.(uint))
+    })
+    if err != nil {
+        if !respondBudgetExceeded(c, err) && !respondPolicyViolation(c, err) && !respondTripOverlap(c, err) {
+            respondSaveError(c, err, "Erro ao atualizar status")
+        }
         return
     }
 
     print_status(fmt.Sprintf("Status atualizado: %s -> %s para %s", oldStatus, req.Status, request.RequesterName))
-    log.Printf("📧 [NOTIFICATION] Status do pedido de %s alterado de '%s' para '%s'", 
-        request.RequesterName, oldStatus, request.Status)
 
+    setETag(c, request)
     c.JSON(http.StatusOK, request)
 }
 
 func cancelTravelRequestHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
     id := c.Param("id")
 
-    var request TravelRequest
-    if err := db.Where("id = ?", id).First(&request).Error; err != nil {
+    request, err := findVisibleTravelRequest(c, id)
+    if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
         return
     }
 
-    if request.Status == "cancelado" {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Pedido já está cancelado"})
+    if !checkIfMatch(c, request) {
+        return
+    }
+
+    if request.Status == StatusCancelled {
+        c.JSON(http.StatusConflict, gin.H{"error": "Pedido já está cancelado", "allowed_transitions": []string{}})
+        return
+    }
+
+    // Regra de negócio: Permite cancelar pedidos aprovados. Usuários com quem o
+    // pedido foi apenas compartilhado não podem cancelá-lo.
+    if _, ok := authorizeTransition(c, request, StatusCancelled); !ok {
         return
     }
 
-    // Regra de negócio: Permite cancelar pedidos aprovados
+    // Corpo opcional com o motivo do cancelamento
+    var req CancelTravelRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
     oldStatus := request.Status
-    request.Status = "cancelado"
+    request.Status = StatusCancelled
     
-    if err := db.Save(&request).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cancelar pedido"})
+    err = db.Transaction(func(tx *gorm.DB) error {
+        if err := saveTravelRequest(tx, &request); err != nil {
+            return err
+        }
+        if err := recordStatusChange(tx, request, oldStatus, userID.(uint), req.Reason); err != nil {
+            return err
+        }
+        err := enqueueEvent(tx, TravelRequestEvent{
+            Type:       statusEventType(request.Status),
+            Request:    request,
+            FromStatus: oldStatus,
+            ActorID:    userID.(uint),
+            Reason:     req.Reason,
+        })
+        if err != nil {
+            return err
+        }
+        return syncApprovalChain(tx, request, userID.(uint))
+    })
+    if err != nil {
+        respondSaveError(c, err, "Erro ao cancelar pedido")
         return
     }
 
     print_status(fmt.Sprintf("Pedido cancelado: %s (era %s)", request.RequesterName, oldStatus))
-    log.Printf("📧 [NOTIFICATION] Pedido de viagem de %s foi cancelado (anterior: %s)", 
-        request.RequesterName, oldStatus)
 
+    setETag(c, request)
     c.JSON(http.StatusOK, gin.H{
         "message": "Pedido cancelado com sucesso",
         "request": request,
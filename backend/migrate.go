@@ -0,0 +1,273 @@
+package main
+
+import (
+    "embed"
+    "errors"
+    "fmt"
+    "io/fs"
+    "path"
+    "regexp"
+    "sort"
+    "strconv"
+    "text/tabwriter"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// Chave do advisory lock do Postgres que serializa réplicas migrando ao mesmo tempo
+const migrationLockKey = 7_184_204_511
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migração versionada com scripts de ida e volta
+type migration struct {
+    Version int64
+    Name    string
+    Up      string
+    Down    string
+}
+
+// Registro das migrações aplicadas
+type SchemaMigration struct {
+    Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+    Name      string
+    AppliedAt time.Time
+}
+
+// Situação de uma migração, usada pelo "migrate status"
+type migrationState struct {
+    Version   int64
+    Name      string
+    AppliedAt *time.Time
+}
+
+// loadMigrations lê e ordena as migrações do diretório "migrations" do FS.
+// Toda migração precisa ter os scripts .up.sql e .down.sql.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+    entries, err := fs.ReadDir(fsys, "migrations")
+    if err != nil {
+        return nil, err
+    }
+
+    byVersion := map[int64]*migration{}
+    for _, entry := range entries {
+        match := migrationFilePattern.FindStringSubmatch(entry.Name())
+        if match == nil {
+            return nil, fmt.Errorf("nome de migração inválido: %s", entry.Name())
+        }
+
+        version, _ := strconv.ParseInt(match[1], 10, 64)
+        content, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+        if err != nil {
+            return nil, err
+        }
+
+        m, ok := byVersion[version]
+        if !ok {
+            m = &migration{Version: version, Name: match[2]}
+            byVersion[version] = m
+        }
+        if m.Name != match[2] {
+            return nil, fmt.Errorf("migração %d com nomes divergentes: %s e %s", version, m.Name, match[2])
+        }
+        if match[3] == "up" {
+            m.Up = string(content)
+        } else {
+            m.Down = string(content)
+        }
+    }
+
+    migrations := make([]migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.Up == "" || m.Down == "" {
+            return nil, fmt.Errorf("migração %04d_%s precisa dos scripts up e down", m.Version, m.Name)
+        }
+        migrations = append(migrations, *m)
+    }
+    sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+    return migrations, nil
+}
+
+// withMigrationLock executa fn em uma única conexão, segurando o advisory
+// lock no Postgres para que apenas uma réplica migre por vez
+func withMigrationLock(database *gorm.DB, fn func(conn *gorm.DB) error) error {
+    return database.Connection(func(conn *gorm.DB) error {
+        if conn.Dialector.Name() != "postgres" {
+            return fn(conn)
+        }
+
+        if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+            return err
+        }
+        defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+
+        return fn(conn)
+    })
+}
+
+func appliedMigrations(conn *gorm.DB) (map[int64]SchemaMigration, error) {
+    timestampType := "TIMESTAMPTZ"
+    if conn.Dialector.Name() != "postgres" {
+        timestampType = "TIMESTAMP"
+    }
+    err := conn.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS schema_migrations (
+        version    BIGINT PRIMARY KEY,
+        name       TEXT NOT NULL,
+        applied_at %s NOT NULL
+    )`, timestampType)).Error
+    if err != nil {
+        return nil, err
+    }
+
+    var rows []SchemaMigration
+    if err := conn.Order("version ASC").Find(&rows).Error; err != nil {
+        return nil, err
+    }
+
+    applied := map[int64]SchemaMigration{}
+    for _, row := range rows {
+        applied[row.Version] = row
+    }
+    return applied, nil
+}
+
+// migrateUp aplica, em ordem, todas as migrações pendentes. Cada migração
+// roda em sua própria transação junto com o registro em schema_migrations.
+func migrateUp(database *gorm.DB, fsys fs.FS) error {
+    migrations, err := loadMigrations(fsys)
+    if err != nil {
+        return err
+    }
+
+    return withMigrationLock(database, func(conn *gorm.DB) error {
+        applied, err := appliedMigrations(conn)
+        if err != nil {
+            return err
+        }
+
+        for _, m := range migrations {
+            if _, ok := applied[m.Version]; ok {
+                continue
+            }
+
+            err := conn.Transaction(func(tx *gorm.DB) error {
+                if err := tx.Exec(m.Up).Error; err != nil {
+                    return err
+                }
+                return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+            })
+            if err != nil {
+                return fmt.Errorf("migração %04d_%s falhou: %w", m.Version, m.Name, err)
+            }
+            print_status(fmt.Sprintf("Migração aplicada: %04d_%s", m.Version, m.Name))
+        }
+        return nil
+    })
+}
+
+// migrateDown reverte as últimas "steps" migrações aplicadas
+func migrateDown(database *gorm.DB, fsys fs.FS, steps int) error {
+    migrations, err := loadMigrations(fsys)
+    if err != nil {
+        return err
+    }
+
+    return withMigrationLock(database, func(conn *gorm.DB) error {
+        applied, err := appliedMigrations(conn)
+        if err != nil {
+            return err
+        }
+
+        for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+            m := migrations[i]
+            if _, ok := applied[m.Version]; !ok {
+                continue
+            }
+
+            err := conn.Transaction(func(tx *gorm.DB) error {
+                if err := tx.Exec(m.Down).Error; err != nil {
+                    return err
+                }
+                return tx.Delete(&SchemaMigration{}, m.Version).Error
+            })
+            if err != nil {
+                return fmt.Errorf("reversão da migração %04d_%s falhou: %w", m.Version, m.Name, err)
+            }
+            print_status(fmt.Sprintf("Migração revertida: %04d_%s", m.Version, m.Name))
+            steps--
+        }
+        return nil
+    })
+}
+
+// migrationStatus lista todas as migrações conhecidas e quando foram aplicadas
+func migrationStatus(database *gorm.DB, fsys fs.FS) ([]migrationState, error) {
+    migrations, err := loadMigrations(fsys)
+    if err != nil {
+        return nil, err
+    }
+
+    applied, err := appliedMigrations(database)
+    if err != nil {
+        return nil, err
+    }
+
+    states := make([]migrationState, 0, len(migrations))
+    for _, m := range migrations {
+        state := migrationState{Version: m.Version, Name: m.Name}
+        if row, ok := applied[m.Version]; ok {
+            appliedAt := row.AppliedAt
+            state.AppliedAt = &appliedAt
+        }
+        states = append(states, state)
+    }
+    return states, nil
+}
+
+// runMigrateCommand implementa o subcomando "migrate up|down [n]|status"
+func runMigrateCommand(args []string) error {
+    if len(args) == 0 {
+        return errors.New("uso: migrate up | migrate down [n] | migrate status")
+    }
+
+    switch args[0] {
+    case "up":
+        if err := migrateUp(db, embeddedMigrations); err != nil {
+            return err
+        }
+        print_status("Banco de dados atualizado")
+    case "down":
+        steps := 1
+        if len(args) > 1 {
+            parsed, err := strconv.Atoi(args[1])
+            if err != nil || parsed < 1 {
+                return fmt.Errorf("número de migrações a reverter inválido: %s", args[1])
+            }
+            steps = parsed
+        }
+        return migrateDown(db, embeddedMigrations, steps)
+    case "status":
+        states, err := migrationStatus(db, embeddedMigrations)
+        if err != nil {
+            return err
+        }
+        w := tabwriter.NewWriter(cliOutput, 0, 0, 2, ' ', 0)
+        fmt.Fprintln(w, "VERSÃO\tNOME\tAPLICADA EM")
+        for _, state := range states {
+            appliedAt := "pendente"
+            if state.AppliedAt != nil {
+                appliedAt = state.AppliedAt.Format(time.RFC3339)
+            }
+            fmt.Fprintf(w, "%04d\t%s\t%s\n", state.Version, state.Name, appliedAt)
+        }
+        return w.Flush()
+    default:
+        return fmt.Errorf("subcomando de migrate desconhecido: %s", args[0])
+    }
+    return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+    "path/filepath"
+    "testing"
+    "testing/fstest"
+
+    "github.com/stretchr/testify/assert"
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+)
+
+func TestEmbeddedMigrationsAreWellFormed(t *testing.T) {
+    migrations, err := loadMigrations(embeddedMigrations)
+    assert.NoError(t, err)
+    assert.NotEmpty(t, migrations)
+    
+    for i, m := range migrations {
+        assert.Equal(t, int64(i+1), m.Version, "versões devem ser sequenciais")
+    }
+}
+
+func TestMigrateUpDownAndStatus(t *testing.T) {
+    database, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{})
+    assert.NoError(t, err)
+    
+    fsys := fstest.MapFS{
+        "migrations/0001_create_trips.up.sql":   {Data: []byte("CREATE TABLE trips (id INTEGER PRIMARY KEY, destination TEXT);")},
+        "migrations/0001_create_trips.down.sql": {Data: []byte("DROP TABLE trips;")},
+        "migrations/0002_add_status.up.sql":     {Data: []byte("ALTER TABLE trips ADD COLUMN status TEXT;")},
+        "migrations/0002_add_status.down.sql":   {Data: []byte("ALTER TABLE trips DROP COLUMN status;")},
+    }
+    
+    assert.NoError(t, migrateUp(database, fsys))
+    assert.True(t, database.Migrator().HasColumn("trips", "status"))
+    
+    // Rodar novamente não reaplica nada
+    assert.NoError(t, migrateUp(database, fsys))
+    
+    states, err := migrationStatus(database, fsys)
+    assert.NoError(t, err)
+    assert.Len(t, states, 2)
+    assert.NotNil(t, states[0].AppliedAt)
+    assert.NotNil(t, states[1].AppliedAt)
+    
+    assert.NoError(t, migrateDown(database, fsys, 1))
+    assert.False(t, database.Migrator().HasColumn("trips", "status"))
+    assert.True(t, database.Migrator().HasTable("trips"))
+    
+    states, _ = migrationStatus(database, fsys)
+    assert.NotNil(t, states[0].AppliedAt)
+    assert.Nil(t, states[1].AppliedAt)
+}
+
+func TestFailedMigrationIsRolledBack(t *testing.T) {
+    database, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{})
+    assert.NoError(t, err)
+    
+    fsys := fstest.MapFS{
+        "migrations/0001_broken.up.sql":   {Data: []byte("CREATE TABLE trips (id INTEGER PRIMARY KEY); INSERT INTO missing VALUES (1);")},
+        "migrations/0001_broken.down.sql": {Data: []byte("DROP TABLE trips;")},
+    }
+    
+    assert.Error(t, migrateUp(database, fsys))
+    
+    states, _ := migrationStatus(database, fsys)
+    assert.Nil(t, states[0].AppliedAt)
+}
+
+func TestMigrationWithoutDownScriptIsRejected(t *testing.T) {
+    fsys := fstest.MapFS{
+        "migrations/0001_create_trips.up.sql": {Data: []byte("CREATE TABLE trips (id INTEGER PRIMARY KEY);")},
+    }
+    
+    _, err := loadMigrations(fsys)
+    assert.Error(t, err)
+}
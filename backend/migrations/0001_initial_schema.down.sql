@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS travel_requests;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,24 @@
+-- Esquema inicial, equivalente ao criado pelo antigo db.AutoMigrate.
+-- IF NOT EXISTS permite adotar bancos já criados pelo AutoMigrate.
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT,
+    email      TEXT,
+    password   TEXT,
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS travel_requests (
+    id             BIGSERIAL PRIMARY KEY,
+    requester_name TEXT,
+    destination    TEXT,
+    departure_date TIMESTAMPTZ,
+    return_date    TIMESTAMPTZ,
+    status         TEXT DEFAULT 'solicitado',
+    user_id        BIGINT,
+    created_by_id  BIGINT,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ
+);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS travel_request_shares;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_manager;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role;
+ALTER TABLE users DROP COLUMN IF EXISTS manager_id;
+ALTER TABLE users DROP COLUMN IF EXISTS department;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
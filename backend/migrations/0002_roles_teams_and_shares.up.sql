@@ -0,0 +1,19 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT DEFAULT 'requester';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS department TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS manager_id BIGINT;
+
+UPDATE users SET role = 'requester' WHERE role IS NULL;
+
+ALTER TABLE users ADD CONSTRAINT chk_users_role
+    CHECK (role IN ('requester', 'approver', 'manager', 'finance', 'director', 'admin'));
+ALTER TABLE users ADD CONSTRAINT fk_users_manager
+    FOREIGN KEY (manager_id) REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS travel_request_shares (
+    id                BIGSERIAL PRIMARY KEY,
+    travel_request_id BIGINT REFERENCES travel_requests (id) ON DELETE CASCADE,
+    user_id           BIGINT REFERENCES users (id) ON DELETE CASCADE,
+    created_at        TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_share_request_user ON travel_request_shares (travel_request_id, user_id);
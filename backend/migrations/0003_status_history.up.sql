@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS status_histories (
+    id                BIGSERIAL PRIMARY KEY,
+    travel_request_id BIGINT REFERENCES travel_requests (id) ON DELETE CASCADE,
+    from_status       TEXT,
+    to_status         TEXT,
+    actor_id          BIGINT REFERENCES users (id),
+    reason            TEXT,
+    created_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_histories_travel_request_id ON status_histories (travel_request_id);
+
+-- Pedidos existentes ganham um registro de criação no histórico
+INSERT INTO status_histories (travel_request_id, from_status, to_status, actor_id, reason, created_at)
+SELECT id, '', status, created_by_id, 'Registro retroativo', created_at
+FROM travel_requests
+WHERE NOT EXISTS (SELECT 1 FROM status_histories h WHERE h.travel_request_id = travel_requests.id);
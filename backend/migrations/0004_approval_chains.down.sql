@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS approval_steps;
+
+ALTER TABLE travel_requests DROP CONSTRAINT IF EXISTS chk_travel_requests_dates;
+ALTER TABLE travel_requests DROP CONSTRAINT IF EXISTS chk_travel_requests_status;
+ALTER TABLE travel_requests DROP CONSTRAINT IF EXISTS chk_travel_requests_estimated_cost;
+ALTER TABLE travel_requests DROP COLUMN IF EXISTS international;
+ALTER TABLE travel_requests DROP COLUMN IF EXISTS estimated_cost;
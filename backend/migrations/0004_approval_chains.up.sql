@@ -0,0 +1,26 @@
+ALTER TABLE travel_requests ADD COLUMN IF NOT EXISTS estimated_cost BIGINT DEFAULT 0;
+ALTER TABLE travel_requests ADD COLUMN IF NOT EXISTS international BOOLEAN DEFAULT FALSE;
+
+ALTER TABLE travel_requests ADD CONSTRAINT chk_travel_requests_estimated_cost
+    CHECK (estimated_cost >= 0);
+ALTER TABLE travel_requests ADD CONSTRAINT chk_travel_requests_status
+    CHECK (status IN ('rascunho', 'solicitado', 'aprovado', 'rejeitado', 'em_viagem', 'concluido', 'cancelado'));
+ALTER TABLE travel_requests ADD CONSTRAINT chk_travel_requests_dates
+    CHECK (return_date >= departure_date);
+
+CREATE TABLE IF NOT EXISTS approval_steps (
+    id                BIGSERIAL PRIMARY KEY,
+    travel_request_id BIGINT REFERENCES travel_requests (id) ON DELETE CASCADE,
+    position          BIGINT,
+    name              TEXT,
+    approver_id       BIGINT REFERENCES users (id),
+    approver_role     TEXT,
+    status            TEXT DEFAULT 'pendente',
+    decided_by_id     BIGINT REFERENCES users (id),
+    decided_at        TIMESTAMPTZ,
+    comment           TEXT,
+    created_at        TIMESTAMPTZ,
+    CONSTRAINT chk_approval_steps_status CHECK (status IN ('pendente', 'aprovado', 'rejeitado', 'descartado'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_steps_travel_request_id ON approval_steps (travel_request_id);
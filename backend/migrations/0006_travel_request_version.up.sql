@@ -0,0 +1 @@
+ALTER TABLE travel_requests ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notifications;
+
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT DEFAULT 'pt-BR';
+
+UPDATE users SET locale = 'pt-BR' WHERE locale IS NULL;
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT REFERENCES users (id) ON DELETE CASCADE,
+    event             TEXT,
+    travel_request_id BIGINT REFERENCES travel_requests (id) ON DELETE CASCADE,
+    title             TEXT,
+    body              TEXT,
+    read_at           TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications (user_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id                 BIGSERIAL PRIMARY KEY,
+    event_type         TEXT NOT NULL,
+    travel_request_id  BIGINT,
+    payload            TEXT NOT NULL,
+    status             TEXT NOT NULL DEFAULT 'pending',
+    attempts           BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at    TIMESTAMPTZ NOT NULL,
+    delivered_channels TEXT,
+    last_error         TEXT,
+    delivered_at       TIMESTAMPTZ,
+    created_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_travel_request_id ON outbox_events (travel_request_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox_events (status, next_attempt_at);
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id            BIGSERIAL PRIMARY KEY,
+    url           TEXT NOT NULL,
+    events        TEXT,
+    secret        TEXT NOT NULL,
+    active        BOOLEAN DEFAULT TRUE,
+    created_by_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        BIGINT,
+    event_type      TEXT,
+    payload         TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    response_status BIGINT,
+    response_body   TEXT,
+    last_error      TEXT,
+    delivered_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries (status, next_attempt_at);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id      BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users (id) ON DELETE CASCADE,
+    event   TEXT,
+    inbox   BOOLEAN NOT NULL DEFAULT TRUE,
+    email   BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_preferences_user_event ON notification_preferences (user_id, event);
+
+-- Contagem de não lidas da caixa de entrada
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications (user_id) WHERE read_at IS NULL;
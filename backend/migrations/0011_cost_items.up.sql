@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS cost_items (
+    id                BIGSERIAL PRIMARY KEY,
+    travel_request_id BIGINT REFERENCES travel_requests (id) ON DELETE CASCADE,
+    category          TEXT NOT NULL,
+    description       TEXT,
+    amount            BIGINT NOT NULL,
+    currency          CHAR(3) NOT NULL DEFAULT 'BRL',
+    exchange_rate     DOUBLE PRECISION NOT NULL DEFAULT 1,
+    base_amount       BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_items_travel_request_id ON cost_items (travel_request_id);
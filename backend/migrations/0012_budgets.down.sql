@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS budgets;
+DROP INDEX IF EXISTS idx_travel_requests_cost_center;
+ALTER TABLE travel_requests DROP COLUMN IF EXISTS cost_center;
@@ -0,0 +1,22 @@
+ALTER TABLE travel_requests ADD COLUMN IF NOT EXISTS cost_center TEXT;
+
+-- Pedidos existentes passam a consumir o orçamento da equipe do solicitante
+UPDATE travel_requests tr
+SET cost_center = u.department
+FROM users u
+WHERE u.id = tr.user_id AND tr.cost_center IS NULL AND u.department <> '';
+
+CREATE INDEX IF NOT EXISTS idx_travel_requests_cost_center ON travel_requests (cost_center);
+
+CREATE TABLE IF NOT EXISTS budgets (
+    id            BIGSERIAL PRIMARY KEY,
+    cost_center   TEXT NOT NULL,
+    period_start  TIMESTAMPTZ NOT NULL,
+    period_end    TIMESTAMPTZ NOT NULL,
+    amount        BIGINT NOT NULL,
+    created_by_id BIGINT REFERENCES users (id),
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_budgets_cost_center ON budgets (cost_center);
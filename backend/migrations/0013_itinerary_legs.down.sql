@@ -0,0 +1 @@
+DROP TABLE IF EXISTS itinerary_legs;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS itinerary_legs (
+    id                BIGSERIAL PRIMARY KEY,
+    travel_request_id BIGINT REFERENCES travel_requests (id) ON DELETE CASCADE,
+    position          INTEGER NOT NULL,
+    origin            TEXT NOT NULL,
+    destination       TEXT NOT NULL,
+    date              TIMESTAMPTZ NOT NULL,
+    transport_mode    TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_itinerary_legs_travel_request_id ON itinerary_legs (travel_request_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_itinerary_legs_destination_id;
+DROP INDEX IF EXISTS idx_travel_requests_destination_id;
+
+ALTER TABLE itinerary_legs DROP COLUMN IF EXISTS destination_id;
+ALTER TABLE itinerary_legs DROP COLUMN IF EXISTS origin_id;
+ALTER TABLE travel_requests DROP COLUMN IF EXISTS destination_id;
@@ -0,0 +1,7 @@
+-- Pedidos existentes ficam sem destination_id até "request normalize-destinations"
+ALTER TABLE travel_requests ADD COLUMN IF NOT EXISTS destination_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE itinerary_legs ADD COLUMN IF NOT EXISTS origin_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE itinerary_legs ADD COLUMN IF NOT EXISTS destination_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_travel_requests_destination_id ON travel_requests (destination_id);
+CREATE INDEX IF NOT EXISTS idx_itinerary_legs_destination_id ON itinerary_legs (destination_id);
@@ -0,0 +1 @@
+ALTER TABLE travel_requests DROP COLUMN IF EXISTS policy_warnings;
@@ -0,0 +1,2 @@
+-- Alertas (regras soft) da política de viagens, em JSON
+ALTER TABLE travel_requests ADD COLUMN IF NOT EXISTS policy_warnings TEXT;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_travel_requests_user_period;
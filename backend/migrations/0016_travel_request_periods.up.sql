@@ -0,0 +1,2 @@
+-- Busca de viagens sobrepostas do mesmo viajante
+CREATE INDEX IF NOT EXISTS idx_travel_requests_user_period ON travel_requests (user_id, departure_date, return_date);
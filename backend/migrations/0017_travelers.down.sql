@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS booking_delegations;
+
+DROP INDEX IF EXISTS idx_travel_requests_traveler_period;
+CREATE INDEX IF NOT EXISTS idx_travel_requests_user_period ON travel_requests (user_id, departure_date, return_date);
+
+DROP INDEX IF EXISTS idx_travel_requests_traveler_id;
+ALTER TABLE travel_requests DROP COLUMN IF EXISTS traveler_id;
@@ -0,0 +1,27 @@
+-- Pedidos existentes têm como viajante o próprio responsável
+ALTER TABLE travel_requests ADD COLUMN IF NOT EXISTS traveler_id BIGINT REFERENCES users (id);
+
+UPDATE travel_requests SET traveler_id = user_id WHERE traveler_id IS NULL;
+
+-- requester_name passa a ser derivado do viajante
+UPDATE travel_requests tr
+SET requester_name = u.name
+FROM users u
+WHERE u.id = tr.traveler_id;
+
+CREATE INDEX IF NOT EXISTS idx_travel_requests_traveler_id ON travel_requests (traveler_id);
+
+-- A busca de sobreposições passa a ser por viajante
+DROP INDEX IF EXISTS idx_travel_requests_user_period;
+CREATE INDEX IF NOT EXISTS idx_travel_requests_traveler_period ON travel_requests (traveler_id, departure_date, return_date);
+
+CREATE TABLE IF NOT EXISTS booking_delegations (
+    id            BIGSERIAL PRIMARY KEY,
+    principal_id  BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    delegate_id   BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_by_id BIGINT REFERENCES users (id),
+    created_at    TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_delegations_pair ON booking_delegations (principal_id, delegate_id);
+CREATE INDEX IF NOT EXISTS idx_booking_delegations_delegate_id ON booking_delegations (delegate_id);
@@ -0,0 +1,4 @@
+ALTER TABLE status_histories DROP COLUMN IF EXISTS on_behalf_of_id;
+ALTER TABLE approval_steps DROP COLUMN IF EXISTS on_behalf_of_id;
+
+DROP TABLE IF EXISTS approval_delegations;
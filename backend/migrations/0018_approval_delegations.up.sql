@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS approval_delegations (
+    id            BIGSERIAL PRIMARY KEY,
+    approver_id   BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    delegate_id   BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    start_date    TIMESTAMPTZ NOT NULL,
+    end_date      TIMESTAMPTZ NOT NULL,
+    reason        TEXT NOT NULL DEFAULT '',
+    created_by_id BIGINT REFERENCES users (id),
+    created_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_delegations_approver_id ON approval_delegations (approver_id);
+CREATE INDEX IF NOT EXISTS idx_approval_delegations_delegate_id ON approval_delegations (delegate_id);
+
+-- Decisões tomadas por substitutos guardam o aprovador ausente
+ALTER TABLE approval_steps ADD COLUMN IF NOT EXISTS on_behalf_of_id BIGINT REFERENCES users (id);
+ALTER TABLE status_histories ADD COLUMN IF NOT EXISTS on_behalf_of_id BIGINT REFERENCES users (id);
@@ -0,0 +1,8 @@
+-- Posição do evento no stream SSE, atribuída na ordem de confirmação.
+-- O BIGSERIAL é reservado antes do commit e não serve como cursor.
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS stream_seq BIGINT;
+
+-- Eventos existentes mantêm o ID como posição, preservando o Last-Event-ID dos clientes
+UPDATE outbox_events SET stream_seq = id WHERE stream_seq IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_stream_seq ON outbox_events (stream_seq);
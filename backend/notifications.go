@@ -0,0 +1,579 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "mime"
+    "net/http"
+    "net/smtp"
+    "strings"
+    "text/template"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// Tipos de evento de domínio dos pedidos de viagem
+const (
+    EventRequestCreated       = "request.created"
+    EventRequestStatusChanged = "request.status_changed"
+    EventRequestCancelled     = "request.cancelled"
+    EventRequestReassigned    = "request.reassigned" // novo responsável (CLI)
+    EventApprovalRequested    = "approval.requested" // nova etapa aguardando decisão
+)
+
+// Idiomas suportados nas mensagens; pt-BR é o padrão
+const (
+    LocalePortuguese = "pt-BR"
+    LocaleEnglish    = "en"
+)
+
+// Relação do destinatário com o pedido
+const (
+    RecipientRequester = "requester" // dono ou criador do pedido
+    RecipientApprover  = "approver"  // responsável pela etapa de aprovação atual
+    RecipientWatcher   = "watcher"   // usuário com quem o pedido foi compartilhado
+)
+
+// TravelRequestEvent descreve uma mudança em um pedido de viagem
+type TravelRequestEvent struct {
+    ID         uint          `json:"id"` // ID do evento no outbox
+    Type       string        `json:"type"`
+    Request    TravelRequest `json:"request"`
+    FromStatus string        `json:"from_status,omitempty"`
+    ActorID    uint          `json:"actor_id"`
+    Reason     string        `json:"reason,omitempty"`
+    StepName   string        `json:"step_name,omitempty"` // etapa de approval.requested
+    OccurredAt time.Time     `json:"occurred_at"`
+    // Destinatários calculados no momento do evento; os dados do usuário
+    // (email, idioma) são carregados na entrega
+    Recipients []Recipient `json:"recipients"`
+    // Posição no stream SSE; não faz parte do payload gravado
+    StreamSeq uint64 `json:"-"`
+}
+
+type Recipient struct {
+    User     User   `json:"-"`
+    UserID   uint   `json:"user_id"`
+    Relation string `json:"relation"`
+}
+
+// Notifier é um canal de entrega de notificações (email, webhook, in-app...)
+type Notifier interface {
+    Name() string
+    Notify(event TravelRequestEvent, recipients []Recipient) error
+}
+
+// Notificação exibida na caixa de entrada do usuário
+type Notification struct {
+    ID              uint       `json:"id" gorm:"primaryKey"`
+    UserID          uint       `json:"user_id" gorm:"index"`
+    Event           string     `json:"event"`
+    TravelRequestID uint       `json:"travel_request_id"`
+    Title           string     `json:"title"`
+    Body            string     `json:"body"`
+    ReadAt          *time.Time `json:"read_at"`
+    CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Canais ativos; email e webhook fixo são habilitados por loadNotifiers
+var notifiers = []Notifier{logNotifier{}, inAppNotifier{}, subscriptionsNotifier{}}
+
+// loadNotifiers habilita os canais configurados por variáveis de ambiente
+func loadNotifiers() {
+    notifiers = []Notifier{logNotifier{}, inAppNotifier{}, subscriptionsNotifier{}}
+
+    if host := getEnv("SMTP_HOST", ""); host != "" {
+        notifier := smtpNotifier{
+            Addr: host + ":" + getEnv("SMTP_PORT", "1025"),
+            From: getEnv("SMTP_FROM", "viagens@travel-requests.local"),
+        }
+        if username := getEnv("SMTP_USERNAME", ""); username != "" {
+            notifier.Auth = smtp.PlainAuth("", username, getEnv("SMTP_PASSWORD", ""), host)
+        }
+        notifiers = append(notifiers, notifier)
+    }
+
+    if url := getEnv("NOTIFICATION_WEBHOOK_URL", ""); url != "" {
+        notifiers = append(notifiers, webhookNotifier{URL: url, Client: &http.Client{Timeout: 5 * time.Second}})
+    }
+
+    names := make([]string, 0, len(notifiers))
+    for _, n := range notifiers {
+        names = append(names, n.Name())
+    }
+    print_status("Canais de notificação: " + strings.Join(names, ", "))
+}
+
+// resolveRecipients define quem recebe o evento: os aprovadores da etapa
+// atual em approval.requested; nos demais, solicitante e usuários com quem o
+// pedido foi compartilhado. Quem executou a ação não é notificado.
+// Recebe a transação da mudança de estado para enxergar a cadeia já atualizada.
+func resolveRecipients(tx *gorm.DB, event TravelRequestEvent) ([]Recipient, error) {
+    request := event.Request
+    seen := map[uint]bool{event.ActorID: true}
+    recipients := []Recipient{}
+
+    add := func(users []User, relation string) {
+        for _, user := range users {
+            if seen[user.ID] {
+                continue
+            }
+            seen[user.ID] = true
+            recipients = append(recipients, Recipient{User: user, UserID: user.ID, Relation: relation})
+        }
+    }
+
+    if event.Type == EventApprovalRequested {
+        approvers, err := stepApprovers(tx, request)
+        if err != nil {
+            return nil, err
+        }
+        add(approvers, RecipientApprover)
+        substitutes, err := activeSubstitutes(tx, approvers)
+        if err != nil {
+            return nil, err
+        }
+        add(substitutes, RecipientApprover)
+        return recipients, nil
+    }
+
+    var requesters []User
+    if err := tx.Where("id IN ?", []uint{request.UserID, request.CreatedByID, request.TravelerID}).Order("id").Find(&requesters).Error; err != nil {
+        return nil, err
+    }
+    add(requesters, RecipientRequester)
+
+    var watchers []User
+    err := tx.Where("id IN (?)", tx.Model(&TravelRequestShare{}).Select("user_id").Where("travel_request_id = ?", request.ID)).
+        Order("id").Find(&watchers).Error
+    if err != nil {
+        return nil, err
+    }
+    add(watchers, RecipientWatcher)
+
+    return recipients, nil
+}
+
+// stepApprovers lista quem pode decidir a etapa atual do pedido. Etapas
+// abertas a qualquer aprovador ficam com os aprovadores da equipe do
+// solicitante, os mesmos que enxergam o pedido.
+func stepApprovers(tx *gorm.DB, request TravelRequest) ([]User, error) {
+    users := []User{}
+    if request.Status != StatusRequested {
+        return users, nil
+    }
+
+    var step ApprovalStep
+    err := tx.Where("travel_request_id = ? AND status = ?", request.ID, StepPending).
+        Order("position ASC").First(&step).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return users, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    query := tx.Model(&User{}).Order("id")
+    switch {
+    case step.ApproverID != nil:
+        query = query.Where("id = ?", *step.ApproverID)
+    case step.ApproverRole == RoleApprover:
+        var requester User
+        if err := tx.Where("id = ?", request.TravelerID).First(&requester).Error; err != nil {
+            return nil, err
+        }
+        if requester.Department == "" {
+            return users, nil
+        }
+        query = query.Where("role IN ? AND department = ?", []string{RoleApprover, RoleManager}, requester.Department)
+    default:
+        query = query.Where("role = ?", step.ApproverRole)
+    }
+
+    err = query.Find(&users).Error
+    return users, err
+}
+
+// loadRecipients recarrega os usuários dos destinatários registrados no
+// evento. Usuários removidos desde então são ignorados.
+func loadRecipients(recipients []Recipient) ([]Recipient, error) {
+    ids := make([]uint, 0, len(recipients))
+    for _, r := range recipients {
+        ids = append(ids, r.UserID)
+    }
+
+    var users []User
+    if len(ids) > 0 {
+        if err := db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+            return nil, err
+        }
+    }
+    byID := map[uint]User{}
+    for _, user := range users {
+        byID[user.ID] = user
+    }
+
+    loaded := make([]Recipient, 0, len(recipients))
+    for _, r := range recipients {
+        if user, ok := byID[r.UserID]; ok {
+            r.User = user
+            loaded = append(loaded, r)
+        }
+    }
+    return loaded, nil
+}
+
+// Mensagem já renderizada no idioma do destinatário
+type notificationMessage struct {
+    Subject string
+    Body    string
+}
+
+type messageTemplate struct {
+    Subject *template.Template
+    Body    *template.Template
+}
+
+var statusLabels = map[string]map[string]string{
+    LocalePortuguese: {
+        StatusDraft:     "rascunho",
+        StatusRequested: "solicitado",
+        StatusApproved:  "aprovado",
+        StatusRejected:  "rejeitado",
+        StatusTraveling: "em viagem",
+        StatusCompleted: "concluído",
+        StatusCancelled: "cancelado",
+    },
+    LocaleEnglish: {
+        StatusDraft:     "draft",
+        StatusRequested: "requested",
+        StatusApproved:  "approved",
+        StatusRejected:  "rejected",
+        StatusTraveling: "traveling",
+        StatusCompleted: "completed",
+        StatusCancelled: "cancelled",
+    },
+}
+
+var dateLayouts = map[string]string{
+    LocalePortuguese: "02/01/2006",
+    LocaleEnglish:    "2006-01-02",
+}
+
+// Textos por idioma e tipo de evento (assunto e corpo)
+var messageSources = map[string]map[string][2]string{
+    LocalePortuguese: {
+        EventRequestCreated: {
+            `Pedido de viagem #{{.Request.ID}} para {{.Request.Destination}}`,
+            `Olá, {{.Recipient.Name}}.
+
+O pedido de viagem de {{.Request.RequesterName}} para {{.Request.Destination}} foi criado com status "{{status .Request.Status}}".
+
+Ida: {{date .Request.DepartureDate}}
+Volta: {{date .Request.ReturnDate}}
+`,
+        },
+        EventRequestStatusChanged: {
+            `Pedido de viagem #{{.Request.ID}}: {{status .Request.Status}}`,
+            `Olá, {{.Recipient.Name}}.
+
+O status do pedido de viagem de {{.Request.RequesterName}} para {{.Request.Destination}} foi alterado de "{{status .FromStatus}}" para "{{status .Request.Status}}".
+{{- if .Reason}}
+Motivo: {{.Reason}}
+{{- end}}
+`,
+        },
+        EventRequestCancelled: {
+            `Pedido de viagem #{{.Request.ID}} cancelado`,
+            `Olá, {{.Recipient.Name}}.
+
+O pedido de viagem de {{.Request.RequesterName}} para {{.Request.Destination}} foi cancelado (status anterior: "{{status .FromStatus}}").
+{{- if .Reason}}
+Motivo: {{.Reason}}
+{{- end}}
+`,
+        },
+        EventRequestReassigned: {
+            `Pedido de viagem #{{.Request.ID}} reatribuído`,
+            `Olá, {{.Recipient.Name}}.
+
+O pedido de viagem de {{.Request.RequesterName}} para {{.Request.Destination}} foi reatribuído a outro responsável (status: "{{status .Request.Status}}").
+{{- if .Reason}}
+Motivo: {{.Reason}}
+{{- end}}
+`,
+        },
+        EventApprovalRequested: {
+            `Aprovação pendente: pedido de viagem #{{.Request.ID}} para {{.Request.Destination}}`,
+            `Olá, {{.Recipient.Name}}.
+
+O pedido de viagem de {{.Request.RequesterName}} para {{.Request.Destination}} aguarda sua aprovação na etapa "{{.StepName}}".
+
+Ida: {{date .Request.DepartureDate}}
+Volta: {{date .Request.ReturnDate}}
+{{- if .Request.PolicyWarnings}}
+
+Alertas da política de viagens:
+{{- range .Request.PolicyWarnings}}
+- {{.Message}}
+{{- end}}
+{{- end}}
+`,
+        },
+    },
+    LocaleEnglish: {
+        EventRequestCreated: {
+            `Travel request #{{.Request.ID}} to {{.Request.Destination}}`,
+            `Hello, {{.Recipient.Name}}.
+
+{{.Request.RequesterName}}'s travel request to {{.Request.Destination}} was created with status "{{status .Request.Status}}".
+
+Departure: {{date .Request.DepartureDate}}
+Return: {{date .Request.ReturnDate}}
+`,
+        },
+        EventRequestStatusChanged: {
+            `Travel request #{{.Request.ID}}: {{status .Request.Status}}`,
+            `Hello, {{.Recipient.Name}}.
+
+The status of {{.Request.RequesterName}}'s travel request to {{.Request.Destination}} changed from "{{status .FromStatus}}" to "{{status .Request.Status}}".
+{{- if .Reason}}
+Reason: {{.Reason}}
+{{- end}}
+`,
+        },
+        EventRequestCancelled: {
+            `Travel request #{{.Request.ID}} cancelled`,
+            `Hello, {{.Recipient.Name}}.
+
+{{.Request.RequesterName}}'s travel request to {{.Request.Destination}} was cancelled (previous status: "{{status .FromStatus}}").
+{{- if .Reason}}
+Reason: {{.Reason}}
+{{- end}}
+`,
+        },
+        EventRequestReassigned: {
+            `Travel request #{{.Request.ID}} reassigned`,
+            `Hello, {{.Recipient.Name}}.
+
+{{.Request.RequesterName}}'s travel request to {{.Request.Destination}} was reassigned to a new owner (status: "{{status .Request.Status}}").
+{{- if .Reason}}
+Reason: {{.Reason}}
+{{- end}}
+`,
+        },
+        EventApprovalRequested: {
+            `Approval needed: travel request #{{.Request.ID}} to {{.Request.Destination}}`,
+            `Hello, {{.Recipient.Name}}.
+
+{{.Request.RequesterName}}'s travel request to {{.Request.Destination}} is awaiting your approval at the "{{.StepName}}" step.
+
+Departure: {{date .Request.DepartureDate}}
+Return: {{date .Request.ReturnDate}}
+{{- if .Request.PolicyWarnings}}
+
+Travel policy warnings:
+{{- range .Request.PolicyWarnings}}
+- {{.Message}}
+{{- end}}
+{{- end}}
+`,
+        },
+    },
+}
+
+var messageTemplates = parseMessageTemplates()
+
+func parseMessageTemplates() map[string]map[string]messageTemplate {
+    templates := map[string]map[string]messageTemplate{}
+    for locale, events := range messageSources {
+        labels := statusLabels[locale]
+        layout := dateLayouts[locale]
+        funcs := template.FuncMap{
+            "status": func(status string) string {
+                if label, ok := labels[status]; ok {
+                    return label
+                }
+                return status
+            },
+            "date": func(t time.Time) string { return t.Format(layout) },
+        }
+
+        templates[locale] = map[string]messageTemplate{}
+        for eventType, source := range events {
+            name := locale + "/" + eventType
+            templates[locale][eventType] = messageTemplate{
+                Subject: template.Must(template.New(name + "/subject").Funcs(funcs).Parse(source[0])),
+                Body:    template.Must(template.New(name + "/body").Funcs(funcs).Parse(source[1])),
+            }
+        }
+    }
+    return templates
+}
+
+// renderNotification monta assunto e corpo no idioma do destinatário
+func renderNotification(event TravelRequestEvent, recipient Recipient) (notificationMessage, error) {
+    locales, ok := messageTemplates[recipient.User.Locale]
+    if !ok {
+        locales = messageTemplates[LocalePortuguese]
+    }
+    tmpl, ok := locales[event.Type]
+    if !ok {
+        return notificationMessage{}, fmt.Errorf("sem modelo de mensagem para o evento %s", event.Type)
+    }
+
+    data := map[string]interface{}{
+        "Request":    event.Request,
+        "FromStatus": event.FromStatus,
+        "Reason":     event.Reason,
+        "StepName":   event.StepName,
+        "Recipient":  recipient.User,
+        "Relation":   recipient.Relation,
+    }
+
+    var subject, body bytes.Buffer
+    if err := tmpl.Subject.Execute(&subject, data); err != nil {
+        return notificationMessage{}, err
+    }
+    if err := tmpl.Body.Execute(&body, data); err != nil {
+        return notificationMessage{}, err
+    }
+    return notificationMessage{Subject: subject.String(), Body: body.String()}, nil
+}
+
+// logNotifier mantém no log do servidor um registro de cada notificação
+type logNotifier struct{}
+
+func (logNotifier) Name() string { return "log" }
+
+func (logNotifier) Notify(event TravelRequestEvent, recipients []Recipient) error {
+    emails := make([]string, 0, len(recipients))
+    for _, r := range recipients {
+        emails = append(emails, r.User.Email)
+    }
+    log.Printf("📧 [NOTIFICATION] %s: pedido %d de %s para %s (%s) -> [%s]",
+        event.Type, event.Request.ID, event.Request.RequesterName, event.Request.Destination,
+        event.Request.Status, strings.Join(emails, ", "))
+    return nil
+}
+
+// inAppNotifier grava as mensagens na caixa de entrada de cada destinatário
+type inAppNotifier struct{}
+
+func (inAppNotifier) Name() string { return "inapp" }
+
+func (inAppNotifier) Notify(event TravelRequestEvent, recipients []Recipient) error {
+    recipients, err := recipientsWanting(recipients, event.Type, ChannelInbox)
+    if err != nil {
+        return err
+    }
+
+    notifications := make([]Notification, 0, len(recipients))
+    for _, r := range recipients {
+        message, err := renderNotification(event, r)
+        if err != nil {
+            return err
+        }
+        notifications = append(notifications, Notification{
+            UserID:          r.User.ID,
+            Event:           event.Type,
+            TravelRequestID: event.Request.ID,
+            Title:           message.Subject,
+            Body:            message.Body,
+        })
+    }
+
+    if len(notifications) == 0 {
+        return nil
+    }
+    return db.Create(&notifications).Error
+}
+
+// smtpNotifier envia um email por destinatário. Em desenvolvimento aponta
+// para o MailHog do docker-compose.
+type smtpNotifier struct {
+    Addr string
+    From string
+    Auth smtp.Auth
+}
+
+func (smtpNotifier) Name() string { return "smtp" }
+
+func (n smtpNotifier) Notify(event TravelRequestEvent, recipients []Recipient) error {
+    recipients, err := recipientsWanting(recipients, event.Type, ChannelEmail)
+    if err != nil {
+        return err
+    }
+
+    var errs []error
+    for _, r := range recipients {
+        message, err := renderNotification(event, r)
+        if err != nil {
+            errs = append(errs, err)
+            continue
+        }
+        if err := smtp.SendMail(n.Addr, n.Auth, n.From, []string{r.User.Email}, n.buildMessage(r.User.Email, message)); err != nil {
+            errs = append(errs, fmt.Errorf("%s: %w", r.User.Email, err))
+        }
+    }
+    return errors.Join(errs...)
+}
+
+func (n smtpNotifier) buildMessage(to string, message notificationMessage) []byte {
+    var buf bytes.Buffer
+    fmt.Fprintf(&buf, "From: %s\r\n", n.From)
+    fmt.Fprintf(&buf, "To: %s\r\n", to)
+    fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+    fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+    buf.WriteString("MIME-Version: 1.0\r\n")
+    buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+    buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+    buf.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+    return buf.Bytes()
+}
+
+// webhookNotifier publica o evento e seus destinatários em uma URL fixa
+// (ex.: integração com chat). Um POST por evento.
+type webhookNotifier struct {
+    URL    string
+    Client *http.Client
+}
+
+func (webhookNotifier) Name() string { return "webhook" }
+
+func (n webhookNotifier) Notify(event TravelRequestEvent, recipients []Recipient) error {
+    type webhookRecipient struct {
+        UserID   uint   `json:"user_id"`
+        Email    string `json:"email"`
+        Relation string `json:"relation"`
+    }
+
+    // Os destinatários do payload incluem o email, ao contrário dos do evento
+    payload := struct {
+        TravelRequestEvent
+        Recipients []webhookRecipient `json:"recipients"`
+    }{TravelRequestEvent: event, Recipients: []webhookRecipient{}}
+    for _, r := range recipients {
+        payload.Recipients = append(payload.Recipients, webhookRecipient{r.User.ID, r.User.Email, r.Relation})
+    }
+
+    body, err := json.Marshal(payload)
+    if err != nil {
+        return err
+    }
+
+    resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return fmt.Errorf("webhook respondeu %d", resp.StatusCode)
+    }
+    return nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer imita o MailHog: aceita qualquer mensagem e a guarda em memória
+type fakeSMTPServer struct {
+    listener net.Listener
+    mu       sync.Mutex
+    messages []capturedMail
+}
+
+type capturedMail struct {
+    To   string
+    Data string
+}
+
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    assert.NoError(t, err)
+    
+    server := &fakeSMTPServer{listener: listener}
+    go func() {
+        for {
+            conn, err := listener.Accept()
+            if err != nil {
+                return
+            }
+            go server.handle(conn)
+        }
+    }()
+    t.Cleanup(func() { listener.Close() })
+    return server
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+    defer conn.Close()
+    
+    reader := bufio.NewReader(conn)
+    reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+    reply("220 fake-smtp")
+    
+    var mail capturedMail
+    for {
+        line, err := reader.ReadString('\n')
+        if err != nil {
+            return
+        }
+        command := strings.ToUpper(strings.TrimSpace(line))
+        switch {
+        case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+            reply("250 fake-smtp")
+        case strings.HasPrefix(command, "RCPT TO:"):
+            mail.To = strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+            reply("250 OK")
+        case command == "DATA":
+            reply("354 Fim com <CRLF>.<CRLF>")
+            var data strings.Builder
+            for {
+                dataLine, err := reader.ReadString('\n')
+                if err != nil {
+                    return
+                }
+                if dataLine == ".\r\n" {
+                    break
+                }
+                data.WriteString(dataLine)
+            }
+            mail.Data = data.String()
+            s.mu.Lock()
+            s.messages = append(s.messages, mail)
+            s.mu.Unlock()
+            reply("250 OK")
+        case command == "QUIT":
+            reply("221 Bye")
+            return
+        default:
+            reply("250 OK")
+        }
+    }
+}
+
+func (s *fakeSMTPServer) Messages() []capturedMail {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]capturedMail(nil), s.messages...)
+}
+
+func useNotifiers(t *testing.T, channels ...Notifier) {
+    previous := notifiers
+    notifiers = channels
+    t.Cleanup(func() { notifiers = previous })
+}
+
+func TestCreatedRequestAsksManagerForApproval(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    useNotifiers(t, inAppNotifier{})
+    
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    flushOutbox(t)
+    
+    var notifications []Notification
+    db.Where("travel_request_id = ?", requestID).Find(&notifications)
+    assert.Len(t, notifications, 1, "o solicitante não é notificado da própria ação")
+    assert.Equal(t, uint(2), notifications[0].UserID)
+    assert.Equal(t, EventApprovalRequested, notifications[0].Event)
+    assert.Contains(t, notifications[0].Body, "aguarda sua aprovação")
+    assert.Contains(t, notifications[0].Body, "Gestor direto")
+    
+    w := performRequest(router, "POST", fmt.Sprintf("/api/travel-requests/%d/shares", requestID), tokens["requester"],
+        ShareTravelRequest{UserID: 4})
+    assert.Equal(t, 201, w.Code)
+    
+    event := TravelRequestEvent{Type: EventRequestStatusChanged, ActorID: 2}
+    db.First(&event.Request, requestID)
+    recipients, err := resolveRecipients(db, event)
+    assert.NoError(t, err)
+    assert.Len(t, recipients, 2)
+    assert.Equal(t, RecipientRequester, recipients[0].Relation)
+    assert.Equal(t, "requester@example.com", recipients[0].User.Email)
+    assert.Equal(t, RecipientWatcher, recipients[1].Relation)
+    assert.Equal(t, "finance@example.com", recipients[1].User.Email)
+}
+
+func TestStatusChangeEmailUsesRecipientLocale(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    server := startFakeSMTPServer(t)
+    useNotifiers(t, smtpNotifier{Addr: server.listener.Addr().String(), From: "viagens@example.com"})
+    
+    db.Model(&User{}).Where("email = ?", "requester@example.com").Update("locale", LocaleEnglish)
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    
+    w := performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/1/approve", requestID), tokens["manager"], "*", nil)
+    assert.Equal(t, 200, w.Code)
+    flushOutbox(t)
+    
+    messages := server.Messages()
+    assert.Len(t, messages, 2)
+    
+    // Pedido de aprovação: email em português para o gestor
+    assert.Equal(t, "manager@example.com", messages[0].To)
+    assert.Contains(t, messages[0].Data, "aguarda sua aprovação")
+    
+    // Aprovação: email em inglês para o solicitante
+    assert.Equal(t, "requester@example.com", messages[1].To)
+    assert.Contains(t, messages[1].Data, "Content-Type: text/plain; charset=UTF-8")
+    assert.Contains(t, messages[1].Data, `changed from "requested" to "approved"`)
+}
+
+func TestWebhookNotifierPostsEvent(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    var received map[string]interface{}
+    hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        json.Unmarshal(body, &received)
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    defer hook.Close()
+    useNotifiers(t, webhookNotifier{URL: hook.URL, Client: hook.Client()})
+    
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    w := performConditional(router, "DELETE", fmt.Sprintf("/api/travel-requests/%d", requestID), tokens["requester"], "*",
+        CancelTravelRequest{Reason: "Evento adiado"})
+    assert.Equal(t, 200, w.Code)
+    flushOutbox(t)
+    
+    assert.Equal(t, EventRequestCancelled, received["type"])
+    assert.Equal(t, StatusRequested, received["from_status"])
+    assert.Equal(t, "Evento adiado", received["reason"])
+    recipients := received["recipients"].([]interface{})
+    assert.Len(t, recipients, 0, "cadeia descartada e solicitante é o autor")
+}
+
+func TestRenderNotificationFallsBackToPortuguese(t *testing.T) {
+    event := TravelRequestEvent{
+        Type:       EventRequestCancelled,
+        Request:    TravelRequest{ID: 7, RequesterName: "Ana", Destination: "Natal", Status: StatusCancelled},
+        FromStatus: StatusApproved,
+    }
+    
+    message, err := renderNotification(event, Recipient{User: User{Name: "Bia", Locale: "fr"}, Relation: RecipientWatcher})
+    assert.NoError(t, err)
+    assert.Equal(t, "Pedido de viagem #7 cancelado", message.Subject)
+    assert.Contains(t, message.Body, `status anterior: "aprovado"`)
+    
+    _, err = renderNotification(TravelRequestEvent{Type: "request.unknown"}, Recipient{})
+    assert.Error(t, err)
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Situação de um evento no outbox
+const (
+    OutboxPending   = "pending"
+    OutboxDelivered = "delivered"
+    OutboxDead      = "dead" // tentativas esgotadas; só volta à fila por ação manual
+)
+
+const (
+    outboxBatchSize = 50
+    // Tempo de posse de um evento reivindicado; se a réplica cair no meio da
+    // entrega, o evento volta a ficar disponível depois desse prazo
+    outboxClaimLease = time.Minute
+    outboxMaxBackoff = time.Hour
+)
+
+var (
+    outboxMaxAttempts  = 8
+    outboxRetryBase    = 5 * time.Second
+    outboxPollInterval = time.Second
+)
+
+// Evento de domínio gravado na mesma transação da mudança de estado e
+// entregue aos canais de notificação pelo dispatcher em segundo plano
+type OutboxEvent struct {
+    ID                uint       `json:"id" gorm:"primaryKey"`
+    EventType         string     `json:"event_type"`
+    TravelRequestID   uint       `json:"travel_request_id" gorm:"index"`
+    Payload           string     `json:"payload" gorm:"type:text"`
+    Status            string     `json:"status" gorm:"default:'pending';index:idx_outbox_pending,priority:1"`
+    Attempts          int        `json:"attempts"`
+    NextAttemptAt     time.Time  `json:"next_attempt_at" gorm:"index:idx_outbox_pending,priority:2"`
+    DeliveredChannels string     `json:"delivered_channels"` // canais já entregues, separados por vírgula
+    LastError         string     `json:"last_error"`
+    DeliveredAt       *time.Time `json:"delivered_at"`
+    CreatedAt         time.Time  `json:"created_at"`
+    // Posição no stream SSE na ordem de confirmação (assignStreamSequence)
+    StreamSeq *uint64 `json:"stream_seq,omitempty" gorm:"uniqueIndex:idx_outbox_events_stream_seq"`
+}
+
+// enqueueEvent grava o evento no outbox. Deve receber a transação da mudança
+// de estado: se ela for desfeita, o evento também é.
+func enqueueEvent(tx *gorm.DB, event TravelRequestEvent) error {
+    if event.OccurredAt.IsZero() {
+        event.OccurredAt = time.Now()
+    }
+
+    recipients, err := resolveRecipients(tx, event)
+    if err != nil {
+        return err
+    }
+    event.Recipients = recipients
+
+    payload, err := json.Marshal(event)
+    if err != nil {
+        return err
+    }
+
+    entry := OutboxEvent{
+        EventType:       event.Type,
+        TravelRequestID: event.Request.ID,
+        Payload:         string(payload),
+        Status:          OutboxPending,
+        NextAttemptAt:   event.OccurredAt,
+    }
+    if err := tx.Create(&entry).Error; err != nil {
+        return err
+    }
+    return notifyEventListeners(tx, entry.ID)
+}
+
+// statusEventType escolhe o evento de uma mudança de status
+func statusEventType(status string) string {
+    if status == StatusCancelled {
+        return EventRequestCancelled
+    }
+    return EventRequestStatusChanged
+}
+
+// loadOutboxConfig lê os parâmetros de entrega do ambiente
+func loadOutboxConfig() {
+    if value := getEnv("OUTBOX_MAX_ATTEMPTS", ""); value != "" {
+        if n, err := strconv.Atoi(value); err == nil && n > 0 {
+            outboxMaxAttempts = n
+        }
+    }
+    if value := getEnv("OUTBOX_RETRY_BASE", ""); value != "" {
+        if d, err := time.ParseDuration(value); err == nil && d > 0 {
+            outboxRetryBase = d
+        }
+    }
+    if value := getEnv("OUTBOX_POLL_INTERVAL", ""); value != "" {
+        if d, err := time.ParseDuration(value); err == nil && d > 0 {
+            outboxPollInterval = d
+        }
+    }
+}
+
+// startOutboxDispatcher entrega os eventos pendentes em segundo plano.
+// Várias réplicas podem rodar o dispatcher ao mesmo tempo.
+func startOutboxDispatcher() {
+    loadOutboxConfig()
+    print_status(fmt.Sprintf("Dispatcher do outbox iniciado (intervalo %s, até %d tentativas)", outboxPollInterval, outboxMaxAttempts))
+
+    go func() {
+        ticker := time.NewTicker(outboxPollInterval)
+        defer ticker.Stop()
+        for range ticker.C {
+            if _, err := dispatchOutbox(time.Now()); err != nil {
+                log.Printf("Erro no dispatcher do outbox: %v", err)
+            }
+            if _, err := dispatchWebhookDeliveries(time.Now()); err != nil {
+                log.Printf("Erro no envio de webhooks: %v", err)
+            }
+        }
+    }()
+}
+
+// dispatchOutbox processa um lote de eventos vencidos e retorna quantos
+// foram reivindicados por esta réplica
+func dispatchOutbox(now time.Time) (int, error) {
+    var candidates []OutboxEvent
+    err := db.Where("status = ? AND next_attempt_at <= ?", OutboxPending, now).
+        Order("id ASC").Limit(outboxBatchSize).Find(&candidates).Error
+    if err != nil {
+        return 0, err
+    }
+
+    processed := 0
+    for _, entry := range candidates {
+        claimed, err := claimOutboxEvent(&entry, now)
+        if err != nil {
+            return processed, err
+        }
+        if !claimed {
+            continue
+        }
+        processed++
+
+        deliveryErr := deliverOutboxEvent(&entry)
+        if err := completeOutboxEvent(&entry, deliveryErr, time.Now()); err != nil {
+            return processed, err
+        }
+    }
+    return processed, nil
+}
+
+// claimOutboxEvent reserva o evento com UPDATE condicional no número de
+// tentativas: se outra réplica reivindicou antes, nenhuma linha é afetada
+func claimOutboxEvent(entry *OutboxEvent, now time.Time) (bool, error) {
+    leaseUntil := now.Add(outboxClaimLease)
+    result := db.Model(&OutboxEvent{}).
+        Where("id = ? AND status = ? AND attempts = ?", entry.ID, OutboxPending, entry.Attempts).
+        Updates(map[string]interface{}{
+            "attempts":        entry.Attempts + 1,
+            "next_attempt_at": leaseUntil,
+        })
+    if result.Error != nil {
+        return false, result.Error
+    }
+    if result.RowsAffected == 0 {
+        return false, nil
+    }
+
+    entry.Attempts++
+    entry.NextAttemptAt = leaseUntil
+    return true, nil
+}
+
+// deliverOutboxEvent entrega o evento nos canais que ainda não o receberam.
+// Canais que falham são repetidos na próxima tentativa; os demais não.
+func deliverOutboxEvent(entry *OutboxEvent) error {
+    var event TravelRequestEvent
+    if err := json.Unmarshal([]byte(entry.Payload), &event); err != nil {
+        return err
+    }
+    event.ID = entry.ID
+
+    recipients, err := loadRecipients(event.Recipients)
+    if err != nil {
+        return err
+    }
+
+    delivered := map[string]bool{}
+    for _, name := range strings.Split(entry.DeliveredChannels, ",") {
+        if name != "" {
+            delivered[name] = true
+        }
+    }
+
+    var errs []error
+    for _, n := range notifiers {
+        if delivered[n.Name()] {
+            continue
+        }
+        if err := n.Notify(event, recipients); err != nil {
+            errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+            continue
+        }
+        delivered[n.Name()] = true
+    }
+
+    names := make([]string, 0, len(delivered))
+    for name := range delivered {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    entry.DeliveredChannels = strings.Join(names, ",")
+
+    return errors.Join(errs...)
+}
+
+// completeOutboxEvent registra o resultado da tentativa: entregue, nova
+// tentativa com backoff exponencial ou dead letter
+func completeOutboxEvent(entry *OutboxEvent, deliveryErr error, now time.Time) error {
+    updates := map[string]interface{}{
+        "delivered_channels": entry.DeliveredChannels,
+    }
+
+    switch {
+    case deliveryErr == nil:
+        entry.Status = OutboxDelivered
+        entry.DeliveredAt = &now
+        entry.LastError = ""
+        updates["delivered_at"] = now
+    case entry.Attempts >= outboxMaxAttempts:
+        entry.Status = OutboxDead
+        entry.LastError = deliveryErr.Error()
+        log.Printf("Evento %d (%s) movido para dead letter após %d tentativas: %v", entry.ID, entry.EventType, entry.Attempts, deliveryErr)
+    default:
+        entry.LastError = deliveryErr.Error()
+        entry.NextAttemptAt = now.Add(outboxBackoff(entry.Attempts))
+        updates["next_attempt_at"] = entry.NextAttemptAt
+        log.Printf("Evento %d (%s) falhou na tentativa %d, nova tentativa em %s: %v",
+            entry.ID, entry.EventType, entry.Attempts, entry.NextAttemptAt.Format(time.RFC3339), deliveryErr)
+    }
+
+    updates["status"] = entry.Status
+    updates["last_error"] = entry.LastError
+    return db.Model(&OutboxEvent{}).Where("id = ?", entry.ID).Updates(updates).Error
+}
+
+// outboxBackoff dobra a espera a cada tentativa: 5s, 10s, 20s... até 1h
+func outboxBackoff(attempts int) time.Duration {
+    delay := outboxRetryBase
+    for i := 1; i < attempts; i++ {
+        delay *= 2
+        if delay >= outboxMaxBackoff {
+            return outboxMaxBackoff
+        }
+    }
+    return delay
+}
+
+// listOutboxEventsHandler lista eventos do outbox (admin), por padrão os
+// que esgotaram as tentativas
+func listOutboxEventsHandler(c *gin.Context) {
+    status := c.DefaultQuery("status", OutboxDead)
+
+    events := []OutboxEvent{}
+    if err := db.Where("status = ?", status).Order("id DESC").Limit(maxPageLimit).Find(&events).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar eventos"})
+        return
+    }
+
+    c.JSON(http.StatusOK, events)
+}
+
+// retryOutboxEventHandler devolve um evento à fila com as tentativas zeradas
+func retryOutboxEventHandler(c *gin.Context) {
+    var entry OutboxEvent
+    if err := db.Where("id = ?", c.Param("id")).First(&entry).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+        return
+    }
+
+    if entry.Status == OutboxDelivered {
+        c.JSON(http.StatusConflict, gin.H{"error": "Evento já foi entregue"})
+        return
+    }
+
+    entry.Status = OutboxPending
+    entry.Attempts = 0
+    entry.NextAttemptAt = time.Now()
+    err := db.Model(&entry).Select("status", "attempts", "next_attempt_at").Updates(&entry).Error
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao reenfileirar evento"})
+        return
+    }
+
+    c.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "gorm.io/gorm"
+)
+
+// flushOutbox entrega imediatamente os eventos pendentes, como o dispatcher faria
+func flushOutbox(t *testing.T) {
+    _, err := dispatchOutbox(time.Now())
+    assert.NoError(t, err)
+}
+
+// flakyNotifier falha nas primeiras chamadas e depois passa a entregar
+type flakyNotifier struct {
+    failures *int
+    calls    *int
+}
+
+func (flakyNotifier) Name() string { return "flaky" }
+
+func (n flakyNotifier) Notify(event TravelRequestEvent, recipients []Recipient) error {
+    *n.calls++
+    if *n.failures > 0 {
+        *n.failures--
+        return errors.New("servidor indisponível")
+    }
+    return nil
+}
+
+func TestEventIsEnqueuedWithStateChange(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    requestID := createTestTravelRequest(router, token, "Recife")
+    
+    var entries []OutboxEvent
+    db.Order("id ASC").Find(&entries)
+    assert.Len(t, entries, 2)
+    assert.Equal(t, EventRequestCreated, entries[0].EventType)
+    assert.Equal(t, EventApprovalRequested, entries[1].EventType, "primeira etapa da cadeia pede aprovação")
+    assert.Equal(t, OutboxPending, entries[0].Status)
+    assert.Equal(t, requestID, entries[0].TravelRequestID)
+    
+    var event TravelRequestEvent
+    assert.NoError(t, json.Unmarshal([]byte(entries[0].Payload), &event))
+    assert.Equal(t, "Recife", event.Request.Destination)
+    
+    // Transação desfeita não deixa evento para trás
+    db.Transaction(func(tx *gorm.DB) error {
+        enqueueEvent(tx, TravelRequestEvent{Type: EventRequestCancelled, Request: event.Request})
+        return errors.New("falha depois de gravar o evento")
+    })
+    var count int64
+    db.Model(&OutboxEvent{}).Count(&count)
+    assert.Equal(t, int64(2), count)
+    
+    w := performConditional(router, "DELETE", fmt.Sprintf("/api/travel-requests/%d", requestID), token, "*", nil)
+    assert.Equal(t, 200, w.Code)
+    db.Model(&OutboxEvent{}).Where("event_type = ?", EventRequestCancelled).Count(&count)
+    assert.Equal(t, int64(1), count)
+}
+
+func TestDispatcherRetriesOnlyFailedChannels(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    failures, calls := 1, 0
+    useNotifiers(t, inAppNotifier{}, flakyNotifier{failures: &failures, calls: &calls})
+    
+    createTestTravelRequest(router, tokens["requester"], "Recife")
+    
+    now := time.Now()
+    processed, err := dispatchOutbox(now)
+    assert.NoError(t, err)
+    assert.Equal(t, 2, processed)
+    
+    var entry OutboxEvent
+    db.First(&entry)
+    assert.Equal(t, OutboxPending, entry.Status)
+    assert.Equal(t, 1, entry.Attempts)
+    assert.Equal(t, "inapp", entry.DeliveredChannels)
+    assert.Contains(t, entry.LastError, "servidor indisponível")
+    
+    // Antes do backoff nada é reprocessado
+    processed, _ = dispatchOutbox(now)
+    assert.Equal(t, 0, processed)
+    
+    processed, _ = dispatchOutbox(now.Add(outboxRetryBase + time.Second))
+    assert.Equal(t, 1, processed)
+    assert.Equal(t, 3, calls)
+    
+    db.First(&entry)
+    assert.Equal(t, OutboxDelivered, entry.Status)
+    assert.NotNil(t, entry.DeliveredAt)
+    assert.Equal(t, "flaky,inapp", entry.DeliveredChannels)
+    
+    var notifications int64
+    db.Model(&Notification{}).Count(&notifications)
+    assert.Equal(t, int64(1), notifications, "canal já entregue não recebe o evento de novo")
+}
+
+func TestDispatcherDeadLettersAndAdminRetry(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    failures, calls := 100, 0
+    useNotifiers(t, flakyNotifier{failures: &failures, calls: &calls})
+    
+    previous := outboxMaxAttempts
+    outboxMaxAttempts = 2
+    defer func() { outboxMaxAttempts = previous }()
+    
+    createTestTravelRequest(router, tokens["requester"], "Recife")
+    
+    now := time.Now()
+    dispatchOutbox(now)
+    dispatchOutbox(now.Add(time.Hour))
+    
+    var entry OutboxEvent
+    db.First(&entry)
+    assert.Equal(t, OutboxDead, entry.Status)
+    assert.Equal(t, 2, entry.Attempts)
+    
+    // Dead letters não são reprocessados automaticamente
+    processed, _ := dispatchOutbox(now.Add(2 * time.Hour))
+    assert.Equal(t, 0, processed)
+    
+    w := performRequest(router, "GET", "/api/outbox", tokens["requester"], nil)
+    assert.Equal(t, 403, w.Code)
+    
+    w = performRequest(router, "GET", "/api/outbox", tokens["admin"], nil)
+    assert.Equal(t, 200, w.Code)
+    var dead []OutboxEvent
+    json.Unmarshal(w.Body.Bytes(), &dead)
+    assert.Len(t, dead, 2)
+    
+    w = performRequest(router, "POST", fmt.Sprintf("/api/outbox/%d/retry", entry.ID), tokens["admin"], nil)
+    assert.Equal(t, 200, w.Code)
+    
+    failures = 0
+    processed, _ = dispatchOutbox(time.Now())
+    assert.Equal(t, 1, processed)
+    db.First(&entry)
+    assert.Equal(t, OutboxDelivered, entry.Status)
+    
+    w = performRequest(router, "POST", fmt.Sprintf("/api/outbox/%d/retry", entry.ID), tokens["admin"], nil)
+    assert.Equal(t, 409, w.Code)
+}
+
+func TestOutboxClaimIsExclusive(t *testing.T) {
+    setupTestDB()
+    
+    db.Transaction(func(tx *gorm.DB) error {
+        return enqueueEvent(tx, TravelRequestEvent{Type: EventRequestCreated, Request: TravelRequest{ID: 1}})
+    })
+    
+    var first, second OutboxEvent
+    db.First(&first)
+    db.First(&second)
+    
+    claimed, err := claimOutboxEvent(&first, time.Now())
+    assert.NoError(t, err)
+    assert.True(t, claimed)
+    
+    claimed, err = claimOutboxEvent(&second, time.Now())
+    assert.NoError(t, err)
+    assert.False(t, claimed, "outra réplica já reivindicou o evento")
+}
+
+func TestOutboxBackoffIsExponentialAndCapped(t *testing.T) {
+    assert.Equal(t, outboxRetryBase, outboxBackoff(1))
+    assert.Equal(t, 2*outboxRetryBase, outboxBackoff(2))
+    assert.Equal(t, 4*outboxRetryBase, outboxBackoff(3))
+    assert.Equal(t, outboxMaxBackoff, outboxBackoff(30))
+}
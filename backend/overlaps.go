@@ -0,0 +1,164 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Modos de tratamento de viagens sobrepostas do mesmo viajante
+const (
+    OverlapBlock = "block" // recusa o pedido com 409
+    OverlapWarn  = "warn"  // aceita e registra o conflito nos alertas do pedido
+)
+
+// Regra usada nos alertas de sobreposição (policy_warnings)
+const overlapRule = "overlap"
+
+var overlapMode = OverlapWarn
+
+// Status de pedidos que não ocupam mais o período do viajante
+var overlapIgnoredStatuses = []string{StatusDraft, StatusRejected, StatusCancelled}
+
+// Par de pedidos do mesmo viajante com períodos sobrepostos
+type TripConflict struct {
+    TravelerID  uint            `json:"traveler_id"`
+    OverlapDays int             `json:"overlap_days"`
+    Requests    []TravelRequest `json:"requests"`
+}
+
+// Devolvido na criação ou edição quando o pedido se sobrepõe a outros no modo block
+type tripOverlapError struct {
+    Conflicts []TravelRequest
+}
+
+func (e *tripOverlapError) Error() string {
+    return fmt.Sprintf("pedido se sobrepõe a %d viagem(ns) do mesmo viajante", len(e.Conflicts))
+}
+
+// loadOverlapConfig lê o modo de tratamento de sobreposições do ambiente
+func loadOverlapConfig() {
+    switch mode := getEnv("OVERLAP_MODE", overlapMode); mode {
+    case OverlapBlock, OverlapWarn:
+        overlapMode = mode
+    default:
+        print_status(fmt.Sprintf("OVERLAP_MODE inválido (%q); usando %s", mode, overlapMode))
+    }
+}
+
+// overlappingTrips devolve os pedidos em andamento do mesmo viajante (sem
+// rascunhos, rejeitados e cancelados) que compartilham pelo menos um dia com
+// o período do pedido
+func overlappingTrips(tx *gorm.DB, request TravelRequest) ([]TravelRequest, error) {
+    var trips []TravelRequest
+    err := tx.Where("traveler_id = ? AND id <> ? AND status NOT IN ?", request.TravelerID, request.ID, overlapIgnoredStatuses).
+        Where("departure_date <= ? AND return_date >= ?", request.ReturnDate, request.DepartureDate).
+        Order("departure_date ASC, id ASC").
+        Find(&trips).Error
+    return trips, err
+}
+
+// checkTripOverlaps aplica OVERLAP_MODE: no modo block devolve tripOverlapError;
+// no modo warn acrescenta um alerta por conflito. Deve ser chamada depois de
+// applyTravelPolicy, que recalcula os alertas.
+func checkTripOverlaps(tx *gorm.DB, request *TravelRequest) error {
+    conflicts, err := overlappingTrips(tx, *request)
+    if err != nil || len(conflicts) == 0 {
+        return err
+    }
+
+    if overlapMode == OverlapBlock {
+        return &tripOverlapError{Conflicts: conflicts}
+    }
+    for _, conflict := range conflicts {
+        request.PolicyWarnings = append(request.PolicyWarnings, PolicyViolation{
+            Rule:     overlapRule,
+            Severity: PolicySoft,
+            Message: fmt.Sprintf("Período se sobrepõe ao pedido #%d para %s (%s a %s)", conflict.ID, conflict.Destination,
+                conflict.DepartureDate.Format("02/01/2006"), conflict.ReturnDate.Format("02/01/2006")),
+        })
+    }
+    return nil
+}
+
+// respondTripOverlap responde 409 com os pedidos conflitantes se err for uma sobreposição
+func respondTripOverlap(c *gin.Context, err error) bool {
+    var overlap *tripOverlapError
+    if !errors.As(err, &overlap) {
+        return false
+    }
+    c.JSON(http.StatusConflict, gin.H{
+        "error":     "O período da viagem se sobrepõe a outros pedidos do mesmo viajante",
+        "conflicts": overlap.Conflicts,
+    })
+    return true
+}
+
+// overlapDays conta os dias em comum entre dois pedidos
+func overlapDays(a, b TravelRequest) int {
+    start, end := a.DepartureDate, a.ReturnDate
+    if b.DepartureDate.After(start) {
+        start = b.DepartureDate
+    }
+    if b.ReturnDate.Before(end) {
+        end = b.ReturnDate
+    }
+    return int(end.Sub(start)/(24*time.Hour)) + 1
+}
+
+// listTripConflictsHandler lista os pares de pedidos sobrepostos já gravados,
+// para auditoria (admin). Aceita ?traveler_id=.
+func listTripConflictsHandler(c *gin.Context) {
+    query := db.Table("travel_requests AS a").
+        Select("a.id AS first_id, b.id AS second_id").
+        Joins("JOIN travel_requests AS b ON b.traveler_id = a.traveler_id AND b.id > a.id AND b.status NOT IN ? AND a.departure_date <= b.return_date AND b.departure_date <= a.return_date", overlapIgnoredStatuses).
+        Where("a.status NOT IN ?", overlapIgnoredStatuses)
+    if value := c.Query("traveler_id"); value != "" {
+        travelerID, err := strconv.ParseUint(value, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro traveler_id inválido"})
+            return
+        }
+        query = query.Where("a.traveler_id = ?", travelerID)
+    }
+
+    var pairs []struct {
+        FirstID  uint
+        SecondID uint
+    }
+    if err := query.Order("a.id ASC, b.id ASC").Scan(&pairs).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar conflitos"})
+        return
+    }
+
+    ids := []uint{}
+    for _, pair := range pairs {
+        ids = append(ids, pair.FirstID, pair.SecondID)
+    }
+    var requests []TravelRequest
+    if err := db.Where("id IN ?", ids).Find(&requests).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar conflitos"})
+        return
+    }
+    byID := map[uint]TravelRequest{}
+    for _, request := range requests {
+        byID[request.ID] = request
+    }
+
+    conflicts := []TripConflict{}
+    for _, pair := range pairs {
+        first, second := byID[pair.FirstID], byID[pair.SecondID]
+        conflicts = append(conflicts, TripConflict{
+            TravelerID:  first.TravelerID,
+            OverlapDays: overlapDays(first, second),
+            Requests:    []TravelRequest{first, second},
+        })
+    }
+
+    c.JSON(http.StatusOK, gin.H{"data": conflicts, "total": len(conflicts)})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+// withOverlapMode troca OVERLAP_MODE durante o teste
+func withOverlapMode(t *testing.T, mode string) {
+    previous := overlapMode
+    overlapMode = mode
+    t.Cleanup(func() { overlapMode = previous })
+}
+
+func tripBetween(destination string, from, to int) CreateTravelRequest {
+    return CreateTravelRequest{
+        Destination:   destination,
+        DepartureDate: daysFromNow(from),
+        ReturnDate:    daysFromNow(to),
+    }
+}
+
+func TestOverlappingTripIsWarnedByDefault(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    other := registerAndLogin(router, "Other", "other@example.com", "")
+    
+    w := performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Recife", 10, 15))
+    assert.Equal(t, 201, w.Code)
+    performRequest(router, "POST", "/api/travel-requests", other, tripBetween("Natal", 10, 15))
+    
+    w = performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Salvador", 15, 18))
+    assert.Equal(t, 201, w.Code)
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Len(t, created.PolicyWarnings, 1, "só os pedidos do mesmo viajante")
+    assert.Equal(t, overlapRule, created.PolicyWarnings[0].Rule)
+    assert.Contains(t, created.PolicyWarnings[0].Message, "pedido #1 para Recife")
+    
+    // Pedidos cancelados não contam
+    db.Model(&TravelRequest{}).Where("id = ?", 1).Update("status", StatusCancelled)
+    w = performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Natal", 11, 12))
+    var afterCancel TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &afterCancel)
+    assert.Empty(t, afterCancel.PolicyWarnings)
+    
+    // Rascunhos e pedidos rejeitados também não
+    db.Model(&TravelRequest{}).Where("id = ?", 3).Update("status", StatusRejected)
+    db.Model(&TravelRequest{}).Where("id = ?", 4).Update("status", StatusDraft)
+    w = performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Aracaju", 11, 16))
+    var afterReject TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &afterReject)
+    assert.Empty(t, afterReject.PolicyWarnings)
+}
+
+func TestOverlapIsRecheckedOnApproval(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    performRequest(router, "POST", "/api/travel-requests", tokens["requester"], tripBetween("Recife", 10, 15))
+    w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], tripBetween("Natal", 12, 18))
+    var second TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &second)
+    assert.Len(t, second.PolicyWarnings, 1)
+    
+    // A reavaliação da política na aprovação mantém o alerta de sobreposição
+    step, _ := currentApprovalStep(second.ID)
+    w = performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", second.ID, step.ID), tokens["manager"], "*", nil)
+    assert.Equal(t, 200, w.Code)
+    var approved TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &approved)
+    assert.Equal(t, StatusApproved, approved.Status)
+    assert.Len(t, approved.PolicyWarnings, 1)
+    assert.Equal(t, overlapRule, approved.PolicyWarnings[0].Rule)
+    
+    // No modo block a aprovação do pedido sobreposto é recusada
+    withOverlapMode(t, OverlapBlock)
+    step, _ = currentApprovalStep(1)
+    w = performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/1/approvals/%d/approve", step.ID), tokens["manager"], "*", nil)
+    assert.Equal(t, 409, w.Code)
+    assert.Contains(t, w.Body.String(), "conflicts")
+    pending, _ := currentApprovalStep(1)
+    assert.Equal(t, step.ID, pending.ID, "etapa continua pendente")
+    
+    previous := approvalPolicy
+    approvalPolicy = ApprovalPolicy{}
+    t.Cleanup(func() { approvalPolicy = previous })
+    w = performConditional(router, "PUT", "/api/travel-requests/1/status", tokens["admin"], "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 409, w.Code)
+    assert.Contains(t, w.Body.String(), "se sobrepõe")
+    
+    var first TravelRequest
+    db.First(&first, 1)
+    assert.Equal(t, StatusRequested, first.Status)
+}
+
+func TestOverlappingTripIsBlocked(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    withOverlapMode(t, OverlapBlock)
+    
+    performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Recife", 10, 15))
+    
+    w := performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Natal", 12, 20))
+    assert.Equal(t, 409, w.Code)
+    var response struct {
+        Conflicts []TravelRequest `json:"conflicts"`
+    }
+    json.Unmarshal(w.Body.Bytes(), &response)
+    assert.Len(t, response.Conflicts, 1)
+    assert.Equal(t, uint(1), response.Conflicts[0].ID)
+    
+    w = performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Natal", 16, 20))
+    assert.Equal(t, 201, w.Code)
+    
+    // A edição também é verificada, ignorando o próprio pedido
+    update := UpdateTravelRequest{Destination: "Natal", DepartureDate: daysFromNow(16), ReturnDate: daysFromNow(22)}
+    w = performConditional(router, "PUT", "/api/travel-requests/2", token, "*", update)
+    assert.Equal(t, 200, w.Code)
+    
+    update.DepartureDate = daysFromNow(14)
+    w = performConditional(router, "PUT", "/api/travel-requests/2", token, "*", update)
+    assert.Equal(t, 409, w.Code)
+}
+
+func TestListTripConflicts(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    admin := registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Recife", 10, 15))
+    performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Natal", 13, 20))
+    performRequest(router, "POST", "/api/travel-requests", token, tripBetween("Salvador", 30, 32))
+    performRequest(router, "POST", "/api/travel-requests", admin, tripBetween("Recife", 10, 15))
+    
+    w := performRequest(router, "GET", "/api/travel-requests/conflicts", admin, nil)
+    assert.Equal(t, 200, w.Code)
+    var response struct {
+        Data  []TripConflict `json:"data"`
+        Total int            `json:"total"`
+    }
+    json.Unmarshal(w.Body.Bytes(), &response)
+    assert.Equal(t, 1, response.Total)
+    assert.Equal(t, uint(2), response.Data[0].TravelerID)
+    assert.Equal(t, 3, response.Data[0].OverlapDays)
+    assert.Equal(t, "Recife", response.Data[0].Requests[0].Destination)
+    assert.Equal(t, "Natal", response.Data[0].Requests[1].Destination)
+    
+    w = performRequest(router, "GET", fmt.Sprintf("/api/travel-requests/conflicts?traveler_id=%d", 1), admin, nil)
+    json.Unmarshal(w.Body.Bytes(), &response)
+    assert.Equal(t, 0, response.Total)
+    
+    w = performRequest(router, "GET", "/api/travel-requests/conflicts", token, nil)
+    assert.Equal(t, 403, w.Code)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "net/url"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+const (
+    defaultPageLimit = 20
+    maxPageLimit     = 100
+)
+
+// Colunas permitidas no parâmetro "sort" e se são do tipo data
+var sortableColumns = map[string]bool{
+    "departure_date": true,
+    "destination":    false,
+    "status":         false,
+    "created_at":     true,
+}
+
+// Parâmetros de paginação e ordenação de uma listagem
+type pageParams struct {
+    Sort   string
+    Order  string
+    Limit  int
+    Page   int
+    Cursor *pageCursor
+}
+
+// Cursor opaco: valor da coluna de ordenação e ID do último item da página
+type pageCursor struct {
+    Value string `json:"v"`
+    ID    uint   `json:"id"`
+}
+
+// Metadados de paginação devolvidos junto com a listagem
+type pageMeta struct {
+    Total      int64  `json:"total"`
+    Limit      int    `json:"limit"`
+    Page       int    `json:"page,omitempty"`
+    Sort       string `json:"sort"`
+    Order      string `json:"order"`
+    NextCursor string `json:"next_cursor,omitempty"`
+    Next       string `json:"next,omitempty"`
+}
+
+func parsePageParams(c *gin.Context) (pageParams, error) {
+    params := pageParams{
+        Sort:  c.DefaultQuery("sort", "created_at"),
+        Order: c.DefaultQuery("order", "desc"),
+        Limit: defaultPageLimit,
+        Page:  1,
+    }
+
+    if _, ok := sortableColumns[params.Sort]; !ok {
+        return params, fmt.Errorf("Ordenação inválida. Use: departure_date, destination, status ou created_at")
+    }
+    if params.Order != "asc" && params.Order != "desc" {
+        return params, fmt.Errorf("Direção de ordenação inválida. Use: asc ou desc")
+    }
+
+    if limit := c.Query("limit"); limit != "" {
+        parsed, err := strconv.Atoi(limit)
+        if err != nil || parsed < 1 {
+            return params, fmt.Errorf("Parâmetro limit deve ser um número positivo")
+        }
+        if parsed > maxPageLimit {
+            parsed = maxPageLimit
+        }
+        params.Limit = parsed
+    }
+
+    if cursor := c.Query("cursor"); cursor != "" {
+        decoded, err := decodePageCursor(cursor)
+        if err != nil {
+            return params, fmt.Errorf("Cursor inválido")
+        }
+        params.Cursor = &decoded
+        params.Page = 0
+    } else if page := c.Query("page"); page != "" {
+        parsed, err := strconv.Atoi(page)
+        if err != nil || parsed < 1 {
+            return params, fmt.Errorf("Parâmetro page deve ser um número positivo")
+        }
+        params.Page = parsed
+    }
+
+    return params, nil
+}
+
+func encodePageCursor(cursor pageCursor) string {
+    data, _ := json.Marshal(cursor)
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageCursor(encoded string) (pageCursor, error) {
+    var cursor pageCursor
+    data, err := base64.RawURLEncoding.DecodeString(encoded)
+    if err != nil {
+        return cursor, err
+    }
+    err = json.Unmarshal(data, &cursor)
+    return cursor, err
+}
+
+// sortValue extrai do pedido o valor da coluna de ordenação, como texto
+func sortValue(request TravelRequest, column string) string {
+    switch column {
+    case "departure_date":
+        return request.DepartureDate.Format(time.RFC3339Nano)
+    case "destination":
+        return request.Destination
+    case "status":
+        return request.Status
+    default:
+        return request.CreatedAt.Format(time.RFC3339Nano)
+    }
+}
+
+// paginate aplica ordenação, cursor/offset e limite à consulta.
+// Busca um item a mais que o limite para saber se existe próxima página.
+func paginate(query *gorm.DB, params pageParams) (*gorm.DB, error) {
+    column := "travel_requests." + params.Sort
+    direction := "DESC"
+    comparison := "<"
+    if params.Order == "asc" {
+        direction = "ASC"
+        comparison = ">"
+    }
+
+    if params.Cursor != nil {
+        var value interface{} = params.Cursor.Value
+        if sortableColumns[params.Sort] {
+            parsed, err := time.Parse(time.RFC3339Nano, params.Cursor.Value)
+            if err != nil {
+                return nil, fmt.Errorf("Cursor inválido")
+            }
+            value = parsed
+        }
+        query = query.Where(
+            fmt.Sprintf("%s %s ? OR (%s = ? AND travel_requests.id %s ?)", column, comparison, column, comparison),
+            value, value, params.Cursor.ID,
+        )
+    } else if params.Page > 1 {
+        query = query.Offset((params.Page - 1) * params.Limit)
+    }
+
+    return query.Order(fmt.Sprintf("%s %s, travel_requests.id %s", column, direction, direction)).Limit(params.Limit + 1), nil
+}
+
+// buildPageMeta monta os metadados e corta o item extra buscado por paginate
+func buildPageMeta(c *gin.Context, requests []TravelRequest, total int64, params pageParams) ([]TravelRequest, pageMeta) {
+    meta := pageMeta{
+        Total: total,
+        Limit: params.Limit,
+        Page:  params.Page,
+        Sort:  params.Sort,
+        Order: params.Order,
+    }
+
+    if len(requests) > params.Limit {
+        requests = requests[:params.Limit]
+        last := requests[len(requests)-1]
+        meta.NextCursor = encodePageCursor(pageCursor{Value: sortValue(last, params.Sort), ID: last.ID})
+
+        query := url.Values{}
+        for key, values := range c.Request.URL.Query() {
+            query[key] = values
+        }
+        query.Del("page")
+        query.Set("cursor", meta.NextCursor)
+        meta.Next = c.Request.URL.Path + "?" + query.Encode()
+    }
+
+    return requests, meta
+}
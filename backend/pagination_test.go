@@ -0,0 +1,84 @@
+package main
+
+import (
+    "encoding/json"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+type listResponse struct {
+    Data       []TravelRequest `json:"data"`
+    Pagination pageMeta        `json:"pagination"`
+}
+
+func TestListPaginationWithCursor(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    for _, destination := range []string{"Belém", "Curitiba", "Fortaleza", "Goiânia", "Salvador"} {
+        createTestTravelRequest(router, token, destination)
+    }
+    
+    seen := map[uint]bool{}
+    path := "/api/travel-requests?limit=2&sort=destination&order=asc"
+    var destinations []string
+    for path != "" {
+        w := performRequest(router, "GET", path, token, nil)
+        assert.Equal(t, 200, w.Code)
+        
+        var response listResponse
+        json.Unmarshal(w.Body.Bytes(), &response)
+        assert.Equal(t, int64(5), response.Pagination.Total)
+        assert.LessOrEqual(t, len(response.Data), 2)
+        
+        for _, request := range response.Data {
+            assert.False(t, seen[request.ID], "pedido repetido entre páginas")
+            seen[request.ID] = true
+            destinations = append(destinations, request.Destination)
+        }
+        path = response.Pagination.Next
+    }
+    
+    assert.Equal(t, []string{"Belém", "Curitiba", "Fortaleza", "Goiânia", "Salvador"}, destinations)
+}
+
+func TestListPaginationWithOffset(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    for _, destination := range []string{"Belém", "Curitiba", "Fortaleza"} {
+        createTestTravelRequest(router, token, destination)
+    }
+    
+    w := performRequest(router, "GET", "/api/travel-requests?limit=2&page=2&sort=destination&order=desc", token, nil)
+    
+    var response listResponse
+    json.Unmarshal(w.Body.Bytes(), &response)
+    
+    assert.Equal(t, 200, w.Code)
+    assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+    assert.Len(t, response.Data, 1)
+    assert.Equal(t, "Belém", response.Data[0].Destination)
+    assert.Empty(t, response.Pagination.NextCursor)
+}
+
+func TestListPaginationValidation(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    w := performRequest(router, "GET", "/api/travel-requests?sort=password", token, nil)
+    assert.Equal(t, 400, w.Code)
+    
+    w = performRequest(router, "GET", "/api/travel-requests?cursor=invalido", token, nil)
+    assert.Equal(t, 400, w.Code)
+    
+    w = performRequest(router, "GET", "/api/travel-requests?limit=1000", token, nil)
+    var response listResponse
+    json.Unmarshal(w.Body.Bytes(), &response)
+    assert.Equal(t, maxPageLimit, response.Pagination.Limit)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+)
+
+// Gravidade de uma regra da política de viagens
+const (
+    PolicyHard = "hard" // bloqueia criação, edição e aprovação
+    PolicySoft = "soft" // apenas alerta, guardado no pedido para os aprovadores
+)
+
+// Tipos de regra aceitos na política
+const (
+    RuleMinAdvanceDays   = "min_advance_days"   // antecedência mínima entre o pedido e a ida
+    RuleMaxDurationDays  = "max_duration_days"  // duração máxima, contando ida e volta
+    RuleBlockedCountries = "blocked_countries"  // países de destino proibidos (ISO 3166-1 alfa-2)
+    RuleMaxEstimatedCost = "max_estimated_cost" // custo estimado máximo (centavos)
+)
+
+// Regra declarativa da política de viagens
+type TravelPolicyRule struct {
+    ID                string   `json:"id"`
+    Type              string   `json:"type"`
+    Severity          string   `json:"severity"`
+    Message           string   `json:"message,omitempty"` // Padrão: descrição gerada a partir da regra
+    Days              int      `json:"days,omitempty"`
+    Countries         []string `json:"countries,omitempty"`
+    Amount            int64    `json:"amount,omitempty"`
+    InternationalOnly bool     `json:"international_only,omitempty"` // Aplica só a viagens internacionais
+}
+
+type TravelPolicy struct {
+    Rules []TravelPolicyRule `json:"rules"`
+}
+
+// Política padrão: viagens internacionais com 21 dias de antecedência (alerta)
+// e no máximo 15 dias por viagem. Pode ser substituída via TRAVEL_POLICY_FILE.
+var travelPolicy = TravelPolicy{
+    Rules: []TravelPolicyRule{
+        {ID: "international-advance", Type: RuleMinAdvanceDays, Severity: PolicySoft, Days: 21, InternationalOnly: true},
+        {ID: "max-duration", Type: RuleMaxDurationDays, Severity: PolicyHard, Days: 15},
+    },
+}
+
+// Regra descumprida por um pedido
+type PolicyViolation struct {
+    Rule     string `json:"rule"`
+    Severity string `json:"severity"`
+    Message  string `json:"message"`
+}
+
+// Devolvido quando o pedido descumpre alguma regra hard
+type policyViolationError struct {
+    Violations []PolicyViolation
+    Warnings   []PolicyViolation
+}
+
+func (e *policyViolationError) Error() string {
+    return fmt.Sprintf("pedido descumpre %d regra(s) da política de viagens", len(e.Violations))
+}
+
+// loadTravelPolicy carrega a política de TRAVEL_POLICY_FILE, se definida
+func loadTravelPolicy() {
+    path := getEnv("TRAVEL_POLICY_FILE", "")
+    if path == "" {
+        return
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        log.Fatal("Falha ao ler política de viagens:", err)
+    }
+
+    var policy TravelPolicy
+    if err := json.Unmarshal(data, &policy); err != nil {
+        log.Fatal("Política de viagens inválida:", err)
+    }
+    if err := policy.validate(); err != nil {
+        log.Fatal("Política de viagens inválida:", err)
+    }
+
+    travelPolicy = policy
+    print_status(fmt.Sprintf("Política de viagens carregada de %s (%d regras)", path, len(policy.Rules)))
+}
+
+func (p TravelPolicy) validate() error {
+    seen := map[string]bool{}
+    for i, rule := range p.Rules {
+        if rule.ID == "" {
+            return fmt.Errorf("regra %d sem id", i+1)
+        }
+        if seen[rule.ID] {
+            return fmt.Errorf("id de regra repetido: %s", rule.ID)
+        }
+        seen[rule.ID] = true
+
+        if rule.Severity != PolicyHard && rule.Severity != PolicySoft {
+            return fmt.Errorf("gravidade inválida na regra %q: %s (use hard ou soft)", rule.ID, rule.Severity)
+        }
+        switch rule.Type {
+        case RuleMinAdvanceDays, RuleMaxDurationDays:
+            if rule.Days <= 0 {
+                return fmt.Errorf("regra %q exige days maior que zero", rule.ID)
+            }
+        case RuleBlockedCountries:
+            if len(rule.Countries) == 0 {
+                return fmt.Errorf("regra %q exige a lista countries", rule.ID)
+            }
+        case RuleMaxEstimatedCost:
+            if rule.Amount <= 0 {
+                return fmt.Errorf("regra %q exige amount maior que zero", rule.ID)
+            }
+        default:
+            return fmt.Errorf("tipo de regra desconhecido em %q: %s", rule.ID, rule.Type)
+        }
+    }
+    return nil
+}
+
+// tripCountries devolve os países de destino do pedido que estão no catálogo
+func tripCountries(request TravelRequest) []Destination {
+    ids := []string{request.DestinationID}
+    for _, leg := range request.Legs {
+        ids = append(ids, leg.DestinationID)
+    }
+
+    var places []Destination
+    for _, id := range ids {
+        if id == "" {
+            continue
+        }
+        if place, ok := lookupDestination(id); ok {
+            places = append(places, place)
+        }
+    }
+    return places
+}
+
+// check devolve a mensagem da violação, ou "" se o pedido cumpre a regra.
+// requestedOn é o dia em que o pedido foi criado.
+func (r TravelPolicyRule) check(request TravelRequest, requestedOn time.Time) string {
+    if r.InternationalOnly && !request.International {
+        return ""
+    }
+
+    message := func(format string, args ...interface{}) string {
+        if r.Message != "" {
+            return r.Message
+        }
+        return fmt.Sprintf(format, args...)
+    }
+
+    switch r.Type {
+    case RuleMinAdvanceDays:
+        advance := int(request.DepartureDate.Sub(requestedOn).Hours() / 24)
+        if advance < r.Days {
+            return message("A ida deve ser marcada com pelo menos %d dias de antecedência (faltam %d)", r.Days, advance)
+        }
+    case RuleMaxDurationDays:
+        duration := int(request.ReturnDate.Sub(request.DepartureDate).Hours()/24) + 1
+        if duration > r.Days {
+            return message("A viagem deve durar no máximo %d dias (tem %d)", r.Days, duration)
+        }
+    case RuleBlockedCountries:
+        for _, place := range tripCountries(request) {
+            for _, country := range r.Countries {
+                if strings.EqualFold(place.Country, country) {
+                    return message("Viagens para %s (%s) não são permitidas", place.Name, place.CountryName)
+                }
+            }
+        }
+    case RuleMaxEstimatedCost:
+        if request.EstimatedCost > r.Amount {
+            return message("O custo estimado não pode passar de %s", formatCents(r.Amount))
+        }
+    }
+    return ""
+}
+
+// evaluatePolicy aplica a política vigente ao pedido, separando as violações
+// que bloqueiam dos alertas. A antecedência é medida a partir da criação do
+// pedido: aprovar perto da ida não penaliza quem pediu com folga.
+func evaluatePolicy(request TravelRequest) ([]PolicyViolation, []PolicyViolation) {
+    requestedOn := request.CreatedAt
+    if requestedOn.IsZero() {
+        requestedOn = time.Now()
+    }
+    requestedOn = requestedOn.UTC().Truncate(24 * time.Hour)
+
+    var violations, warnings []PolicyViolation
+    for _, rule := range travelPolicy.Rules {
+        text := rule.check(request, requestedOn)
+        if text == "" {
+            continue
+        }
+        violation := PolicyViolation{Rule: rule.ID, Severity: rule.Severity, Message: text}
+        if rule.Severity == PolicyHard {
+            violations = append(violations, violation)
+        } else {
+            warnings = append(warnings, violation)
+        }
+    }
+    return violations, warnings
+}
+
+// applyTravelPolicy avalia o pedido e guarda nele os alertas. Violações hard
+// viram policyViolationError.
+func applyTravelPolicy(request *TravelRequest) error {
+    violations, warnings := evaluatePolicy(*request)
+    request.PolicyWarnings = warnings
+    if len(violations) > 0 {
+        return &policyViolationError{Violations: violations, Warnings: warnings}
+    }
+    return nil
+}
+
+// respondPolicyViolation responde 422 se err for uma violação da política
+func respondPolicyViolation(c *gin.Context, err error) bool {
+    var violation *policyViolationError
+    if !errors.As(err, &violation) {
+        return false
+    }
+    c.JSON(http.StatusUnprocessableEntity, gin.H{
+        "error":      "Pedido descumpre a política de viagens",
+        "violations": violation.Violations,
+        "warnings":   violation.Warnings,
+    })
+    return true
+}
+
+// formatCents formata centavos como reais ("R$ 1234,56")
+func formatCents(cents int64) string {
+    return fmt.Sprintf("R$ %d,%02d", cents/100, cents%100)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+)
+
+// withTravelPolicy substitui a política de viagens durante o teste
+func withTravelPolicy(t *testing.T, policy TravelPolicy) {
+    previous := travelPolicy
+    travelPolicy = policy
+    t.Cleanup(func() { travelPolicy = previous })
+}
+
+func daysFromNow(days int) string {
+    return time.Now().AddDate(0, 0, days).Format("2006-01-02")
+}
+
+func TestTravelPolicyValidation(t *testing.T) {
+    assert.NoError(t, travelPolicy.validate())
+    
+    invalid := []TravelPolicy{
+        {Rules: []TravelPolicyRule{{Type: RuleMaxDurationDays, Severity: PolicyHard, Days: 10}}},
+        {Rules: []TravelPolicyRule{{ID: "a", Type: RuleMaxDurationDays, Severity: "warn", Days: 10}}},
+        {Rules: []TravelPolicyRule{{ID: "a", Type: RuleMaxDurationDays, Severity: PolicyHard}}},
+        {Rules: []TravelPolicyRule{{ID: "a", Type: RuleBlockedCountries, Severity: PolicyHard}}},
+        {Rules: []TravelPolicyRule{{ID: "a", Type: "max_hotel_stars", Severity: PolicySoft}}},
+        {Rules: []TravelPolicyRule{
+            {ID: "a", Type: RuleMaxEstimatedCost, Severity: PolicySoft, Amount: 100},
+            {ID: "a", Type: RuleMaxEstimatedCost, Severity: PolicyHard, Amount: 200},
+        }},
+    }
+    for i, policy := range invalid {
+        assert.Error(t, policy.validate(), i)
+    }
+}
+
+func TestCreateBlockedByHardPolicyRule(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    w := performRequest(router, "POST", "/api/travel-requests", token, CreateTravelRequest{
+        Destination:   "Recife",
+        DepartureDate: daysFromNow(30),
+        ReturnDate:    daysFromNow(49),
+    })
+    assert.Equal(t, 422, w.Code)
+    
+    var response struct {
+        Violations []PolicyViolation `json:"violations"`
+    }
+    json.Unmarshal(w.Body.Bytes(), &response)
+    assert.Len(t, response.Violations, 1)
+    assert.Equal(t, "max-duration", response.Violations[0].Rule)
+    assert.Contains(t, response.Violations[0].Message, "no máximo 15 dias (tem 20)")
+    
+    var count int64
+    db.Model(&TravelRequest{}).Count(&count)
+    assert.Equal(t, int64(0), count)
+}
+
+func TestSoftPolicyWarningsAreShownToApprovers(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    useNotifiers(t, inAppNotifier{})
+    
+    w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], CreateTravelRequest{
+        Destination:   "Lisboa",
+        DepartureDate: daysFromNow(5),
+        ReturnDate:    daysFromNow(10),
+    })
+    assert.Equal(t, 201, w.Code, "alertas não bloqueiam")
+    
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Len(t, created.PolicyWarnings, 1)
+    assert.Equal(t, "international-advance", created.PolicyWarnings[0].Rule)
+    assert.Equal(t, PolicySoft, created.PolicyWarnings[0].Severity)
+    
+    w = performRequest(router, "GET", "/api/approvals/pending", tokens["manager"], nil)
+    assert.Contains(t, w.Body.String(), "21 dias de antecedência")
+    
+    flushOutbox(t)
+    var notification Notification
+    db.Where("user_id = ?", 2).First(&notification)
+    assert.Contains(t, notification.Body, "Alertas da política de viagens:\n- A ida deve ser marcada")
+}
+
+func TestApprovalReevaluatesTravelPolicy(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], CreateTravelRequest{
+        Destination:   "Lisboa",
+        DepartureDate: daysFromNow(40),
+        ReturnDate:    daysFromNow(45),
+    })
+    assert.Equal(t, 201, w.Code)
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Empty(t, created.PolicyWarnings)
+    
+    // A política muda depois da criação: a aprovação passa a ser bloqueada
+    withTravelPolicy(t, TravelPolicy{Rules: []TravelPolicyRule{
+        {ID: "no-portugal", Type: RuleBlockedCountries, Severity: PolicyHard, Countries: []string{"pt"}},
+        {ID: "cost-cap", Type: RuleMaxEstimatedCost, Severity: PolicySoft, Amount: 100},
+    }})
+    
+    step, _ := currentApprovalStep(created.ID)
+    path := fmt.Sprintf("/api/travel-requests/%d/approvals/%d", created.ID, step.ID)
+    w = performConditional(router, "POST", path+"/approve", tokens["manager"], "*", nil)
+    assert.Equal(t, 422, w.Code)
+    assert.Contains(t, w.Body.String(), "Viagens para Lisboa (Portugal) não são permitidas")
+    
+    pending, _ := currentApprovalStep(created.ID)
+    assert.Equal(t, step.ID, pending.ID, "etapa continua pendente")
+    
+    w = performConditional(router, "POST", path+"/reject", tokens["manager"], "*", ApprovalDecisionRequest{Comment: "Fora da política"})
+    assert.Equal(t, 200, w.Code, "rejeitar continua possível")
+    
+    // Apenas a regra soft: a aprovação passa e guarda o alerta
+    withTravelPolicy(t, TravelPolicy{Rules: []TravelPolicyRule{
+        {ID: "cost-cap", Type: RuleMaxEstimatedCost, Severity: PolicySoft, Amount: 100},
+    }})
+    w = performRequest(router, "POST", "/api/travel-requests", tokens["requester"], CreateTravelRequest{
+        Destination:   "Recife",
+        DepartureDate: daysFromNow(50),
+        ReturnDate:    daysFromNow(55),
+    })
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Empty(t, created.PolicyWarnings)
+    
+    db.Model(&TravelRequest{}).Where("id = ?", created.ID).Update("estimated_cost", 50000)
+    step, _ = currentApprovalStep(created.ID)
+    w = performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", created.ID, step.ID), tokens["manager"], "*", nil)
+    assert.Equal(t, 200, w.Code)
+    var approved TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &approved)
+    assert.Len(t, approved.PolicyWarnings, 1)
+    assert.Contains(t, approved.PolicyWarnings[0].Message, "R$ 1,00")
+}
+
+func TestEditBlockedByHardPolicyRule(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    token := registerAndLogin(router, "Test User", "test@example.com", "")
+    
+    id := createTestTravelRequest(router, token, "Recife")
+    w := performConditional(router, "PUT", fmt.Sprintf("/api/travel-requests/%d", id), token, "*", UpdateTravelRequest{
+        Destination:   "Recife",
+        DepartureDate: daysFromNow(10),
+        ReturnDate:    daysFromNow(40),
+    })
+    assert.Equal(t, 422, w.Code)
+    assert.Contains(t, w.Body.String(), "max-duration")
+}
+
+func TestMinAdvanceMeasuredFromRequestCreation(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    withTravelPolicy(t, TravelPolicy{Rules: []TravelPolicyRule{
+        {ID: "advance", Type: RuleMinAdvanceDays, Severity: PolicyHard, Days: 7},
+    }})
+    
+    w := performRequest(router, "POST", "/api/travel-requests", tokens["requester"], CreateTravelRequest{
+        Destination:   "Recife",
+        DepartureDate: daysFromNow(10),
+        ReturnDate:    daysFromNow(12),
+    })
+    assert.Equal(t, 201, w.Code)
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    
+    // Aprovado uma semana depois, a 3 dias da ida: pedido com 10 dias de folga
+    db.Model(&TravelRequest{}).Where("id = ?", created.ID).Update("created_at", time.Now().AddDate(0, 0, -7))
+    step, _ := currentApprovalStep(created.ID)
+    w = performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", created.ID, step.ID), tokens["manager"], "*", nil)
+    assert.Equal(t, 200, w.Code)
+    
+    // Pedido criado em cima da hora continua bloqueado
+    w = performRequest(router, "POST", "/api/travel-requests", tokens["requester"], CreateTravelRequest{
+        Destination:   "Natal",
+        DepartureDate: daysFromNow(3),
+        ReturnDate:    daysFromNow(4),
+    })
+    assert.Equal(t, 422, w.Code)
+    assert.Contains(t, w.Body.String(), "pelo menos 7 dias de antecedência")
+}
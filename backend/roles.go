@@ -0,0 +1,56 @@
+package main
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+)
+
+// Papéis de usuário
+const (
+    RoleRequester = "requester" // Solicita viagens
+    RoleApprover  = "approver"  // Aprova pedidos de outros usuários
+    RoleManager   = "manager"   // Gestor de equipe, também aprova
+    RoleFinance   = "finance"   // Financeiro, aprova etapas de custo
+    RoleDirector  = "director"  // Diretoria, aprova etapas de viagens internacionais
+    RoleAdmin     = "admin"     // Acesso total, gerencia usuários
+)
+
+var validRoles = map[string]bool{
+    RoleRequester: true,
+    RoleApprover:  true,
+    RoleManager:   true,
+    RoleFinance:   true,
+    RoleDirector:  true,
+    RoleAdmin:     true,
+}
+
+// Papéis que podem aprovar pedidos de viagem
+var approverRoles = []string{RoleApprover, RoleManager, RoleAdmin}
+
+// hasRole indica se o papel está entre os permitidos. Admin sempre tem acesso.
+func hasRole(role string, allowed ...string) bool {
+    if role == RoleAdmin {
+        return true
+    }
+    for _, r := range allowed {
+        if r == role {
+            return true
+        }
+    }
+    return false
+}
+
+// requireRole é a variante de authMiddleware que exige um dos papéis informados.
+// Deve ser usada depois de authMiddleware, que popula "user_role" no contexto.
+func requireRole(roles ...string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        role := c.GetString("user_role")
+        if !hasRole(role, roles...) {
+            c.JSON(http.StatusForbidden, gin.H{"error": "Permissão insuficiente para esta operação"})
+            c.Abort()
+            return
+        }
+        c.Next()
+    }
+}
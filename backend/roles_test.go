@@ -0,0 +1,114 @@
+package main
+
+import (
+    "encoding/json"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestRegistrationNeverGrantsAdmin(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "First", "first@example.com", "")
+    
+    var first User
+    db.Where("email = ?", "first@example.com").First(&first)
+    assert.Equal(t, RoleRequester, first.Role, "nem o primeiro cadastro vira admin")
+}
+
+func TestBootstrapAdminFromEnv(t *testing.T) {
+    setupTestDB()
+    t.Setenv("ADMIN_EMAIL", "root@example.com")
+    t.Setenv("ADMIN_PASSWORD", "s3nh4forte")
+    
+    bootstrapAdmin()
+    bootstrapAdmin()
+    
+    var admins []User
+    db.Where("email = ?", "root@example.com").Find(&admins)
+    assert.Len(t, admins, 1, "idempotente")
+    assert.Equal(t, RoleAdmin, admins[0].Role)
+}
+
+func TestLoginTokenCarriesRole(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    w := performRequest(router, "POST", "/api/auth/login", "", LoginRequest{
+        Email:    "admin@example.com",
+        Password: "password123",
+    })
+    
+    var response map[string]interface{}
+    json.Unmarshal(w.Body.Bytes(), &response)
+    user := response["user"].(map[string]interface{})
+    
+    assert.Equal(t, 200, w.Code)
+    assert.Equal(t, RoleAdmin, user["role"])
+}
+
+func TestRequesterCannotUpdateStatus(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", RoleRequester)
+    otherToken := registerAndLogin(router, "Other", "other@example.com", RoleRequester)
+    
+    performRequest(router, "POST", "/api/travel-requests", ownerToken, CreateTravelRequest{
+        Destination:   "Recife",
+        DepartureDate: "2025-08-15",
+        ReturnDate:    "2025-08-20",
+    })
+    
+    // Compartilhado: o outro usuário enxerga o pedido, mas não pode aprová-lo
+    performRequest(router, "POST", "/api/travel-requests/1/shares", ownerToken, ShareTravelRequest{UserID: 2})
+    
+    w := performConditional(router, "PUT", "/api/travel-requests/1/status", otherToken, "*", UpdateStatusRequest{Status: "aprovado"})
+    
+    assert.Equal(t, 403, w.Code)
+    assert.Contains(t, w.Body.String(), "Permissão insuficiente")
+}
+
+func TestApproverCanUpdateStatus(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", RoleRequester)
+    approverToken := registerAndLogin(router, "Approver", "approver@example.com", RoleApprover)
+    db.Model(&User{}).Where("id IN ?", []uint{1, 2}).Update("department", "Vendas")
+    
+    performRequest(router, "POST", "/api/travel-requests", ownerToken, CreateTravelRequest{
+        Destination:   "Recife",
+        DepartureDate: "2025-08-15",
+        ReturnDate:    "2025-08-20",
+    })
+    
+    w := performConditional(router, "PUT", "/api/travel-requests/1/status", approverToken, "*", UpdateStatusRequest{Status: "aprovado"})
+    
+    assert.Equal(t, 200, w.Code)
+    assert.Contains(t, w.Body.String(), "aprovado")
+}
+
+func TestOnlyAdminCanManageUsers(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    adminToken := registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    requesterToken := registerAndLogin(router, "Requester", "requester@example.com", "")
+    
+    w := performRequest(router, "GET", "/api/users", requesterToken, nil)
+    assert.Equal(t, 403, w.Code)
+    
+    w = performRequest(router, "PUT", "/api/users/2/role", adminToken, UpdateRoleRequest{Role: RoleManager})
+    assert.Equal(t, 200, w.Code)
+    
+    var user User
+    db.First(&user, 2)
+    assert.Equal(t, RoleManager, user.Role)
+    
+    w = performRequest(router, "PUT", "/api/users/2/role", adminToken, UpdateRoleRequest{Role: "superuser"})
+    assert.Equal(t, 400, w.Code)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+)
+
+type ShareTravelRequest struct {
+    UserID uint `json:"user_id" binding:"required"`
+}
+
+func listSharesHandler(c *gin.Context) {
+    request, err := findVisibleTravelRequest(c, c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
+        return
+    }
+
+    var shares []TravelRequestShare
+    db.Where("travel_request_id = ?", request.ID).Order("id ASC").Find(&shares)
+
+    if shares == nil {
+        shares = []TravelRequestShare{}
+    }
+
+    c.JSON(http.StatusOK, shares)
+}
+
+func shareTravelRequestHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
+
+    request, err := findVisibleTravelRequest(c, c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
+        return
+    }
+
+    // Apenas o dono do pedido (ou um admin) pode compartilhá-lo
+    if !isRequestOwner(request, userID.(uint)) && c.GetString("user_role") != RoleAdmin {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o dono do pedido pode compartilhá-lo"})
+        return
+    }
+
+    var req ShareTravelRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var target User
+    if err := db.Where("id = ?", req.UserID).First(&target).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    var existing TravelRequestShare
+    if err := db.Where("travel_request_id = ? AND user_id = ?", request.ID, target.ID).First(&existing).Error; err == nil {
+        c.JSON(http.StatusConflict, gin.H{"error": "Pedido já compartilhado com este usuário"})
+        return
+    }
+
+    share := TravelRequestShare{
+        TravelRequestID: request.ID,
+        UserID:          target.ID,
+    }
+
+    if err := db.Create(&share).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao compartilhar pedido"})
+        return
+    }
+
+    print_status(fmt.Sprintf("Pedido %d compartilhado com %s", request.ID, target.Email))
+    c.JSON(http.StatusCreated, share)
+}
+
+func unshareTravelRequestHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
+
+    request, err := findVisibleTravelRequest(c, c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de viagem não encontrado"})
+        return
+    }
+
+    if !isRequestOwner(request, userID.(uint)) && c.GetString("user_role") != RoleAdmin {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o dono do pedido pode alterar o compartilhamento"})
+        return
+    }
+
+    result := db.Where("travel_request_id = ? AND user_id = ?", request.ID, c.Param("user_id")).Delete(&TravelRequestShare{})
+    if result.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover compartilhamento"})
+        return
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Compartilhamento não encontrado"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Compartilhamento removido com sucesso"})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+)
+
+// Status do pedido de viagem
+const (
+    StatusDraft     = "rascunho"
+    StatusRequested = "solicitado"
+    StatusApproved  = "aprovado"
+    StatusRejected  = "rejeitado"
+    StatusTraveling = "em_viagem"
+    StatusCompleted = "concluido"
+    StatusCancelled = "cancelado"
+)
+
+var validStatuses = map[string]bool{
+    StatusDraft:     true,
+    StatusRequested: true,
+    StatusApproved:  true,
+    StatusRejected:  true,
+    StatusTraveling: true,
+    StatusCompleted: true,
+    StatusCancelled: true,
+}
+
+// Transição permitida entre dois status e quem pode executá-la
+type statusTransition struct {
+    From        string
+    To          string
+    Roles       []string // Papéis que podem executar a transição
+    AllowOwner  bool     // O dono do pedido pode executar mesmo sem os papéis
+    ForbidOwner bool     // O dono do pedido nunca pode executar (ex.: aprovar o próprio pedido)
+}
+
+// Máquina de estados dos pedidos de viagem
+var statusTransitions = []statusTransition{
+    {From: StatusDraft, To: StatusRequested, AllowOwner: true},
+    {From: StatusDraft, To: StatusCancelled, AllowOwner: true},
+    {From: StatusRequested, To: StatusDraft, AllowOwner: true},
+    {From: StatusRequested, To: StatusApproved, Roles: approverRoles, ForbidOwner: true},
+    {From: StatusRequested, To: StatusRejected, Roles: approverRoles, ForbidOwner: true},
+    {From: StatusRequested, To: StatusCancelled, Roles: approverRoles, AllowOwner: true},
+    {From: StatusRejected, To: StatusDraft, AllowOwner: true},
+    {From: StatusApproved, To: StatusTraveling, Roles: approverRoles, AllowOwner: true},
+    {From: StatusApproved, To: StatusCancelled, Roles: approverRoles, AllowOwner: true},
+    {From: StatusTraveling, To: StatusCompleted, Roles: approverRoles, AllowOwner: true},
+}
+
+// findTransition retorna a transição declarada entre os dois status, se existir
+func findTransition(from, to string) (statusTransition, bool) {
+    for _, transition := range statusTransitions {
+        if transition.From == from && transition.To == to {
+            return transition, true
+        }
+    }
+    return statusTransition{}, false
+}
+
+// nextStatuses lista os status alcançáveis a partir do status atual
+func nextStatuses(from string) []string {
+    next := []string{}
+    for _, transition := range statusTransitions {
+        if transition.From == from {
+            next = append(next, transition.To)
+        }
+    }
+    return next
+}
+
+// allows indica se um usuário com o papel informado pode executar a transição
+func (t statusTransition) allows(role string, isOwner bool) bool {
+    if isOwner {
+        if t.ForbidOwner {
+            return false
+        }
+        if t.AllowOwner {
+            return true
+        }
+    }
+    return len(t.Roles) > 0 && hasRole(role, t.Roles...)
+}
+
+// authorizeTransition valida a transição do pedido para o novo status em nome
+// do usuário autenticado. Em caso de falha escreve a resposta e retorna false:
+// 409 com os próximos status legais quando a transição não existe e 403 quando
+// o usuário não pode executá-la. Aprovação e rejeição também são permitidas ao
+// substituto de um aprovador ausente, cujo ID é devolvido.
+func authorizeTransition(c *gin.Context, request TravelRequest, to string) (*uint, bool) {
+    userID, _ := c.Get("user_id")
+
+    transition, ok := findTransition(request.Status, to)
+    if !ok {
+        c.JSON(http.StatusConflict, gin.H{
+            "error":               fmt.Sprintf("Transição de status inválida: %s -> %s", request.Status, to),
+            "current_status":      request.Status,
+            "allowed_transitions": nextStatuses(request.Status),
+        })
+        return nil, false
+    }
+
+    isOwner := isRequestOwner(request, userID.(uint))
+
+    // REGRA DE NEGÓCIO: Usuário que criou o pedido NÃO pode aprová-lo ou rejeitá-lo,
+    // nem como substituto de outro aprovador
+    if isOwner && transition.ForbidOwner {
+        c.JSON(http.StatusForbidden, gin.H{
+            "error": "Você não pode alterar o status de um pedido que você mesmo criou. Outro usuário deve fazer essa alteração.",
+        })
+        return nil, false
+    }
+
+    if transition.allows(c.GetString("user_role"), isOwner) {
+        return nil, true
+    }
+
+    // Aprovar e rejeitar (transições proibidas ao dono) valem também para o
+    // substituto de um aprovador ausente, desde que o pedido não seja dele
+    if transition.ForbidOwner {
+        delegators, _ := activeDelegators(db, userID.(uint))
+        for _, approver := range delegators {
+            if !transition.allows(approver.Role, false) {
+                continue
+            }
+            if isRequestOwner(request, approver.ID) {
+                c.JSON(http.StatusForbidden, gin.H{
+                    "error": "Como substituto, você não pode alterar o status de um pedido criado pelo próprio aprovador substituído.",
+                })
+                return nil, false
+            }
+            return &approver.ID, true
+        }
+    }
+
+    c.JSON(http.StatusForbidden, gin.H{"error": "Permissão insuficiente para esta operação"})
+    return nil, false
+}
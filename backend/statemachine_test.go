@@ -0,0 +1,90 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestStatusTransitionsFollowStateMachine(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    adminToken := registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    
+    id := createTestTravelRequest(router, ownerToken, "Belo Horizonte")
+    path := fmt.Sprintf("/api/travel-requests/%d/status", id)
+    
+    w := performConditional(router, "PUT", path, adminToken, "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 200, w.Code)
+    
+    // Pedido aprovado não pode voltar a ser solicitado
+    w = performConditional(router, "PUT", path, adminToken, "*", UpdateStatusRequest{Status: StatusRequested})
+    assert.Equal(t, 409, w.Code)
+    
+    var response map[string]interface{}
+    json.Unmarshal(w.Body.Bytes(), &response)
+    assert.ElementsMatch(t, []interface{}{StatusTraveling, StatusCancelled}, response["allowed_transitions"])
+    
+    // O dono pode registrar o início e o fim da viagem
+    w = performConditional(router, "PUT", path, ownerToken, "*", UpdateStatusRequest{Status: StatusTraveling})
+    assert.Equal(t, 200, w.Code)
+    
+    w = performConditional(router, "PUT", path, ownerToken, "*", UpdateStatusRequest{Status: StatusCompleted})
+    assert.Equal(t, 200, w.Code)
+    
+    // Pedido concluído não pode ser cancelado
+    w = performConditional(router, "DELETE", fmt.Sprintf("/api/travel-requests/%d", id), ownerToken, "*", nil)
+    assert.Equal(t, 409, w.Code)
+}
+
+func TestDraftSubmittedByOwner(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    adminToken := registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    
+    w := performRequest(router, "POST", "/api/travel-requests", ownerToken, CreateTravelRequest{
+        Destination:   "Florianópolis",
+        DepartureDate: "2025-08-15",
+        ReturnDate:    "2025-08-20",
+        Draft:         true,
+    })
+    assert.Contains(t, w.Body.String(), StatusDraft)
+    
+    // Rascunho ainda não pode ser aprovado
+    w = performConditional(router, "PUT", "/api/travel-requests/1/status", adminToken, "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 409, w.Code)
+    
+    // Apenas o dono envia o rascunho para aprovação
+    w = performConditional(router, "PUT", "/api/travel-requests/1/status", adminToken, "*", UpdateStatusRequest{Status: StatusRequested})
+    assert.Equal(t, 403, w.Code)
+    
+    w = performConditional(router, "PUT", "/api/travel-requests/1/status", ownerToken, "*", UpdateStatusRequest{Status: StatusRequested})
+    assert.Equal(t, 200, w.Code)
+    
+    w = performConditional(router, "PUT", "/api/travel-requests/1/status", adminToken, "*", UpdateStatusRequest{Status: StatusRejected})
+    assert.Equal(t, 200, w.Code)
+    assert.Contains(t, w.Body.String(), StatusRejected)
+}
+
+func TestCancelledRequestCannotBeResurrected(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    adminToken := registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    ownerToken := registerAndLogin(router, "Owner", "owner@example.com", "")
+    
+    id := createTestTravelRequest(router, ownerToken, "Vitória")
+    
+    w := performConditional(router, "DELETE", fmt.Sprintf("/api/travel-requests/%d", id), ownerToken, "*", nil)
+    assert.Equal(t, 200, w.Code)
+    
+    w = performConditional(router, "PUT", fmt.Sprintf("/api/travel-requests/%d/status", id), adminToken, "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 409, w.Code)
+    assert.Contains(t, w.Body.String(), "Transição de status inválida")
+}
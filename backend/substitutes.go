@@ -0,0 +1,222 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Substituição de um aprovador ausente (férias, licença) durante um período.
+// Enquanto vigente, o delegado recebe as aprovações do titular e decide em
+// nome dele; o histórico registra os dois.
+type ApprovalDelegation struct {
+    ID          uint      `json:"id" gorm:"primaryKey"`
+    ApproverID  uint      `json:"approver_id" gorm:"index"`
+    Approver    *User     `json:"approver,omitempty" gorm:"foreignKey:ApproverID"`
+    DelegateID  uint      `json:"delegate_id" gorm:"index"`
+    Delegate    *User     `json:"delegate,omitempty" gorm:"foreignKey:DelegateID"`
+    StartDate   time.Time `json:"start_date"`
+    EndDate     time.Time `json:"end_date"` // Inclusivo
+    Reason      string    `json:"reason"`
+    CreatedByID uint      `json:"created_by_id"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+type ApprovalDelegationInput struct {
+    DelegateID uint   `json:"delegate_id" binding:"required"`
+    ApproverID uint   `json:"approver_id"` // Apenas admin; padrão: o próprio usuário
+    StartDate  string `json:"start_date" binding:"required"`
+    EndDate    string `json:"end_date" binding:"required"`
+    Reason     string `json:"reason"`
+}
+
+// activeDelegators lista os aprovadores ausentes hoje que designaram o usuário como substituto
+func activeDelegators(tx *gorm.DB, delegateID uint) ([]User, error) {
+    today := time.Now().UTC().Truncate(24 * time.Hour)
+
+    var approvers []User
+    err := tx.Where("id IN (?)", tx.Model(&ApprovalDelegation{}).Select("approver_id").
+        Where("delegate_id = ? AND start_date <= ? AND end_date >= ?", delegateID, today, today)).
+        Order("id").Find(&approvers).Error
+    return approvers, err
+}
+
+// activeSubstitutes lista os substitutos vigentes hoje dos aprovadores informados
+func activeSubstitutes(tx *gorm.DB, approvers []User) ([]User, error) {
+    substitutes := []User{}
+    if len(approvers) == 0 {
+        return substitutes, nil
+    }
+
+    ids := make([]uint, 0, len(approvers))
+    for _, approver := range approvers {
+        ids = append(ids, approver.ID)
+    }
+    today := time.Now().UTC().Truncate(24 * time.Hour)
+
+    err := tx.Where("id IN (?)", tx.Model(&ApprovalDelegation{}).Select("delegate_id").
+        Where("approver_id IN ? AND start_date <= ? AND end_date >= ?", ids, today, today)).
+        Order("id").Find(&substitutes).Error
+    return substitutes, err
+}
+
+// stepDecider indica em nome de quem o usuário pode decidir a etapa: nil se
+// ele próprio é aprovador dela, ou o primeiro titular ausente que poderia
+// decidi-la e que o designou como substituto.
+func stepDecider(step ApprovalStep, user User, delegators []User) (*User, bool) {
+    if canDecideStep(step, user) {
+        return nil, true
+    }
+    for i := range delegators {
+        if canDecideStep(step, delegators[i]) {
+            return &delegators[i], true
+        }
+    }
+    return nil, false
+}
+
+// parseApprovalDelegationPeriod valida o período da ausência (YYYY-MM-DD)
+func parseApprovalDelegationPeriod(req ApprovalDelegationInput) (time.Time, time.Time, error) {
+    start, err := time.Parse("2006-01-02", req.StartDate)
+    if err != nil {
+        return start, start, fmt.Errorf("Formato de data inicial inválido (use YYYY-MM-DD)")
+    }
+    end, err := time.Parse("2006-01-02", req.EndDate)
+    if err != nil {
+        return start, end, fmt.Errorf("Formato de data final inválido (use YYYY-MM-DD)")
+    }
+    if end.Before(start) {
+        return start, end, fmt.Errorf("Data final deve ser igual ou posterior à data inicial")
+    }
+    return start, end, nil
+}
+
+// listApprovalDelegationsHandler lista as substituições em que o usuário é
+// titular ou substituto (admin vê todas). ?active=true filtra as vigentes hoje.
+func listApprovalDelegationsHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    query := db.Preload("Approver").Preload("Delegate").Order("start_date ASC, id ASC")
+    if user.Role != RoleAdmin {
+        query = query.Where("approver_id = ? OR delegate_id = ?", user.ID, user.ID)
+    }
+    if c.Query("active") == "true" {
+        today := time.Now().UTC().Truncate(24 * time.Hour)
+        query = query.Where("start_date <= ? AND end_date >= ?", today, today)
+    }
+
+    delegations := []ApprovalDelegation{}
+    if err := query.Find(&delegations).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar substituições"})
+        return
+    }
+
+    c.JSON(http.StatusOK, delegations)
+}
+
+// createApprovalDelegationHandler designa um substituto para as aprovações do
+// usuário no período. Admins podem informar approver_id para qualquer titular.
+func createApprovalDelegationHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    var req ApprovalDelegationInput
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    approverID := user.ID
+    if req.ApproverID != 0 && req.ApproverID != user.ID {
+        if user.Role != RoleAdmin {
+            c.JSON(http.StatusForbidden, gin.H{"error": "Apenas administradores podem designar substitutos para outro usuário"})
+            return
+        }
+        approverID = req.ApproverID
+    }
+    if req.DelegateID == approverID {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "O substituto deve ser outro usuário"})
+        return
+    }
+
+    start, end, err := parseApprovalDelegationPeriod(req)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var approver, delegate User
+    if err := db.Where("id = ?", approverID).First(&approver).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Aprovador não encontrado"})
+        return
+    }
+    if err := db.Where("id = ?", req.DelegateID).First(&delegate).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Substituto não encontrado"})
+        return
+    }
+
+    // Um substituto por vez: períodos do mesmo titular não podem se sobrepor
+    var overlapping int64
+    db.Model(&ApprovalDelegation{}).
+        Where("approver_id = ? AND start_date <= ? AND end_date >= ?", approver.ID, end, start).
+        Count(&overlapping)
+    if overlapping > 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": "Já existe substituto para este aprovador no período"})
+        return
+    }
+
+    delegation := ApprovalDelegation{
+        ApproverID:  approver.ID,
+        DelegateID:  delegate.ID,
+        StartDate:   start,
+        EndDate:     end,
+        Reason:      req.Reason,
+        CreatedByID: user.ID,
+    }
+    if err := db.Create(&delegation).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar substituição"})
+        return
+    }
+    delegation.Approver = &approver
+    delegation.Delegate = &delegate
+
+    print_status(fmt.Sprintf("%s substitui %s nas aprovações de %s a %s", delegate.Email, approver.Email,
+        start.Format("2006-01-02"), end.Format("2006-01-02")))
+    c.JSON(http.StatusCreated, delegation)
+}
+
+// deleteApprovalDelegationHandler encerra a substituição (titular, substituto ou admin)
+func deleteApprovalDelegationHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    var delegation ApprovalDelegation
+    if err := db.Where("id = ?", c.Param("id")).First(&delegation).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Substituição não encontrada"})
+        return
+    }
+    if user.Role != RoleAdmin && delegation.ApproverID != user.ID && delegation.DelegateID != user.ID {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Substituição não encontrada"})
+        return
+    }
+
+    if err := db.Delete(&delegation).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover substituição"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Substituição removida com sucesso"})
+}
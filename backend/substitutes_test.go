@@ -0,0 +1,191 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// delegateApprovals designa o substituto do aprovador entre os dias informados (relativos a hoje)
+func delegateApprovals(t *testing.T, router *gin.Engine, token string, delegateID uint, from, to int) {
+    w := performRequest(router, "POST", "/api/approval-delegations", token, ApprovalDelegationInput{
+        DelegateID: delegateID,
+        StartDate:  daysFromNow(from),
+        EndDate:    daysFromNow(to),
+        Reason:     "Férias",
+    })
+    assert.Equal(t, 201, w.Code)
+}
+
+func TestSubstituteDecidesOnBehalfOfAbsentApprover(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    useNotifiers(t, inAppNotifier{})
+    backup := registerAndLogin(router, "Backup", "backup@example.com", "")
+    delegateApprovals(t, router, tokens["manager"], 6, -1, 5)
+    
+    id := createTestTravelRequest(router, tokens["requester"], "Recife")
+    
+    flushOutbox(t)
+    var notified int64
+    db.Model(&Notification{}).Where("user_id = ?", 6).Count(&notified)
+    assert.Equal(t, int64(1), notified, "substituto recebe o pedido de aprovação")
+    
+    var pending []ApprovalStep
+    w := performRequest(router, "GET", "/api/approvals/pending", backup, nil)
+    json.Unmarshal(w.Body.Bytes(), &pending)
+    assert.Len(t, pending, 1)
+    
+    step, _ := currentApprovalStep(id)
+    w = performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", id, step.ID), backup, "*", ApprovalDecisionRequest{Comment: "Ok"})
+    assert.Equal(t, 200, w.Code)
+    
+    var decided ApprovalStep
+    db.First(&decided, step.ID)
+    assert.Equal(t, uint(6), *decided.DecidedByID)
+    assert.Equal(t, uint(2), *decided.OnBehalfOfID)
+    
+    var history []StatusHistory
+    w = performRequest(router, "GET", fmt.Sprintf("/api/travel-requests/%d/history", id), backup, nil)
+    json.Unmarshal(w.Body.Bytes(), &history)
+    last := history[len(history)-1]
+    assert.Equal(t, StatusApproved, last.ToStatus)
+    assert.Equal(t, uint(6), last.ActorID)
+    assert.Equal(t, uint(2), *last.OnBehalfOfID)
+    assert.Equal(t, "Manager", last.OnBehalfOf.Name)
+}
+
+func TestSubstitutionOnlyAppliesWithinPeriod(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    backup := registerAndLogin(router, "Backup", "backup@example.com", "")
+    delegateApprovals(t, router, tokens["manager"], 6, 10, 20)
+    
+    id := createTestTravelRequest(router, tokens["requester"], "Recife")
+    
+    var pending []ApprovalStep
+    w := performRequest(router, "GET", "/api/approvals/pending", backup, nil)
+    json.Unmarshal(w.Body.Bytes(), &pending)
+    assert.Empty(t, pending)
+    
+    step, _ := currentApprovalStep(id)
+    w = performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", id, step.ID), backup, "*", nil)
+    assert.Equal(t, 404, w.Code)
+}
+
+func TestSubstituteCannotDecideOwnOrApproverRequests(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    backup := registerAndLogin(router, "Backup", "backup@example.com", "")
+    delegateApprovals(t, router, tokens["manager"], 3, 0, 5)
+    db.Model(&User{}).Where("id = ?", 6).Update("manager_id", 2)
+    
+    // O solicitante é substituto do próprio gestor
+    id := createTestTravelRequest(router, tokens["requester"], "Recife")
+    step, _ := currentApprovalStep(id)
+    w := performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", id, step.ID), tokens["requester"], "*", nil)
+    assert.Equal(t, 403, w.Code)
+    assert.Contains(t, w.Body.String(), "que você mesmo criou")
+    
+    // Pedido em nome de outro viajante, reservado pelo substituto
+    db.Create(&BookingDelegation{PrincipalID: 6, DelegateID: 3})
+    w = performRequest(router, "POST", "/api/travel-requests", tokens["requester"], CreateTravelRequest{
+        TravelerID:    6,
+        Destination:   "Natal",
+        DepartureDate: daysFromNow(30),
+        ReturnDate:    daysFromNow(32),
+    })
+    var booked TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &booked)
+    step, _ = currentApprovalStep(booked.ID)
+    w = performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", booked.ID, step.ID), tokens["requester"], "*", nil)
+    assert.Equal(t, 403, w.Code)
+    
+    var pending []ApprovalStep
+    w = performRequest(router, "GET", "/api/approvals/pending", tokens["requester"], nil)
+    json.Unmarshal(w.Body.Bytes(), &pending)
+    assert.Empty(t, pending)
+    
+    // Pedidos do próprio aprovador substituído ficam de fora
+    delegateApprovals(t, router, tokens["admin"], 6, 0, 5)
+    adminRequest := createTestTravelRequest(router, tokens["admin"], "Recife")
+    var onBehalf []ApprovalStep
+    w = performRequest(router, "GET", "/api/approvals/pending", backup, nil)
+    json.Unmarshal(w.Body.Bytes(), &onBehalf)
+    assert.Len(t, onBehalf, 1, "apenas o pedido do solicitante, em nome do admin")
+    assert.Equal(t, id, onBehalf[0].TravelRequestID)
+    
+    step, _ = currentApprovalStep(adminRequest)
+    w = performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/%d/approve", adminRequest, step.ID), backup, "*", nil)
+    assert.Equal(t, 404, w.Code)
+}
+
+func TestSubstituteOnDirectStatusChange(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    previous := approvalPolicy
+    approvalPolicy = ApprovalPolicy{}
+    t.Cleanup(func() { approvalPolicy = previous })
+    
+    backup := registerAndLogin(router, "Backup", "backup@example.com", "")
+    delegateApprovals(t, router, tokens["manager"], 6, 0, 0)
+    
+    id := createTestTravelRequest(router, tokens["requester"], "Recife")
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    w := performConditional(router, "PUT", path+"/status", backup, "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 200, w.Code)
+    
+    var history StatusHistory
+    db.Where("travel_request_id = ? AND to_status = ?", id, StatusApproved).First(&history)
+    assert.Equal(t, uint(6), history.ActorID)
+    assert.Equal(t, uint(2), *history.OnBehalfOfID)
+    
+    // O substituto continua sem poder aprovar o que ele mesmo criou
+    own := createTestTravelRequest(router, backup, "Natal")
+    w = performConditional(router, "PUT", fmt.Sprintf("/api/travel-requests/%d/status", own), backup, "*", UpdateStatusRequest{Status: StatusApproved})
+    assert.Equal(t, 403, w.Code)
+}
+
+func TestApprovalDelegationManagement(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    invalid := []ApprovalDelegationInput{
+        {DelegateID: 2, StartDate: daysFromNow(0), EndDate: daysFromNow(5)},
+        {DelegateID: 4, StartDate: daysFromNow(5), EndDate: daysFromNow(0)},
+        {DelegateID: 4, StartDate: "05/08/2025", EndDate: daysFromNow(5)},
+    }
+    for i, input := range invalid {
+        w := performRequest(router, "POST", "/api/approval-delegations", tokens["manager"], input)
+        assert.Equal(t, 400, w.Code, i)
+    }
+    
+    w := performRequest(router, "POST", "/api/approval-delegations", tokens["manager"], ApprovalDelegationInput{DelegateID: 4, ApproverID: 5, StartDate: daysFromNow(0), EndDate: daysFromNow(5)})
+    assert.Equal(t, 403, w.Code)
+    
+    w = performRequest(router, "POST", "/api/approval-delegations", tokens["admin"], ApprovalDelegationInput{DelegateID: 4, ApproverID: 2, StartDate: daysFromNow(0), EndDate: daysFromNow(5)})
+    assert.Equal(t, 201, w.Code)
+    var delegation ApprovalDelegation
+    json.Unmarshal(w.Body.Bytes(), &delegation)
+    
+    w = performRequest(router, "POST", "/api/approval-delegations", tokens["manager"], ApprovalDelegationInput{DelegateID: 5, StartDate: daysFromNow(5), EndDate: daysFromNow(8)})
+    assert.Equal(t, 409, w.Code, "períodos do mesmo aprovador não se sobrepõem")
+    
+    w = performRequest(router, "POST", "/api/approval-delegations", tokens["manager"], ApprovalDelegationInput{DelegateID: 5, StartDate: daysFromNow(30), EndDate: daysFromNow(40)})
+    assert.Equal(t, 201, w.Code)
+    
+    var active []ApprovalDelegation
+    w = performRequest(router, "GET", "/api/approval-delegations?active=true", tokens["manager"], nil)
+    json.Unmarshal(w.Body.Bytes(), &active)
+    assert.Len(t, active, 1)
+    assert.Equal(t, "Finance", active[0].Delegate.Name)
+    
+    w = performRequest(router, "DELETE", fmt.Sprintf("/api/approval-delegations/%d", delegation.ID), tokens["requester"], nil)
+    assert.Equal(t, 404, w.Code)
+    
+    w = performRequest(router, "DELETE", fmt.Sprintf("/api/approval-delegations/%d", delegation.ID), tokens["manager"], nil)
+    assert.Equal(t, 200, w.Code)
+    
+    var remaining []ApprovalDelegation
+    w = performRequest(router, "GET", "/api/approval-delegations", tokens["finance"], nil)
+    json.Unmarshal(w.Body.Bytes(), &remaining)
+    assert.Empty(t, remaining)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/golang-jwt/jwt/v5"
+    "gorm.io/gorm"
+)
+
+const (
+    accessTokenTTL  = 15 * time.Minute
+    refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// Refresh token armazenado no servidor. Apenas o hash SHA-256 é persistido.
+// Tokens da mesma sessão compartilham o FamilyID; a cada uso o token é
+// rotacionado e reutilizar um token já usado revoga a família inteira.
+type RefreshToken struct {
+    ID        uint       `json:"id" gorm:"primaryKey"`
+    UserID    uint       `json:"user_id" gorm:"index"`
+    FamilyID  string     `json:"family_id" gorm:"index"`
+    TokenHash string     `json:"-" gorm:"uniqueIndex"`
+    ExpiresAt time.Time  `json:"expires_at"`
+    UsedAt    *time.Time `json:"used_at"`
+    RevokedAt *time.Time `json:"revoked_at"`
+    CreatedAt time.Time  `json:"created_at"`
+}
+
+type RefreshTokenRequest struct {
+    RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Par de tokens devolvido no login e na renovação
+type tokenPair struct {
+    AccessToken  string
+    RefreshToken string
+}
+
+func randomToken(size int) (string, error) {
+    buf := make([]byte, size)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
+
+// issueTokenPair gera um access token de curta duração e um novo refresh
+// token na família informada (uma família por sessão de login)
+func issueTokenPair(tx *gorm.DB, user User, familyID string) (tokenPair, error) {
+    jti, err := randomToken(16)
+    if err != nil {
+        return tokenPair{}, err
+    }
+
+    now := time.Now()
+    accessToken, err := jwtKeys.sign(jwt.MapClaims{
+        "user_id": user.ID,
+        "email":   user.Email,
+        "role":    user.Role,
+        "sid":     familyID,
+        "jti":     jti,
+        "exp":     now.Add(accessTokenTTL).Unix(),
+        "iat":     now.Unix(),
+    })
+    if err != nil {
+        return tokenPair{}, err
+    }
+
+    refreshToken, err := randomToken(32)
+    if err != nil {
+        return tokenPair{}, err
+    }
+
+    err = tx.Create(&RefreshToken{
+        UserID:    user.ID,
+        FamilyID:  familyID,
+        TokenHash: hashToken(refreshToken),
+        ExpiresAt: now.Add(refreshTokenTTL),
+    }).Error
+    if err != nil {
+        return tokenPair{}, err
+    }
+
+    return tokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// revokeTokenFamily revoga todos os refresh tokens da sessão. Access tokens
+// da família passam a ser recusados pelo authMiddleware.
+func revokeTokenFamily(tx *gorm.DB, familyID string) error {
+    return tx.Model(&RefreshToken{}).
+        Where("family_id = ? AND revoked_at IS NULL", familyID).
+        Update("revoked_at", time.Now()).Error
+}
+
+// isTokenFamilyRevoked indica se a sessão do access token foi encerrada
+func isTokenFamilyRevoked(familyID string) bool {
+    var active int64
+    db.Model(&RefreshToken{}).Where("family_id = ? AND revoked_at IS NULL", familyID).Count(&active)
+    return active == 0
+}
+
+func tokenResponse(pair tokenPair, user User) gin.H {
+    return gin.H{
+        "token":         pair.AccessToken,
+        "refresh_token": pair.RefreshToken,
+        "expires_in":    int(accessTokenTTL.Seconds()),
+        "user": gin.H{
+            "id":    user.ID,
+            "name":  user.Name,
+            "email": user.Email,
+            "role":  user.Role,
+        },
+    }
+}
+
+func refreshTokenHandler(c *gin.Context) {
+    var req RefreshTokenRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var stored RefreshToken
+    if err := db.Where("token_hash = ?", hashToken(req.RefreshToken)).First(&stored).Error; err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token inválido"})
+        return
+    }
+
+    // Detecção de reutilização: token já rotacionado ou revogado encerra a sessão
+    if stored.UsedAt != nil || stored.RevokedAt != nil {
+        revokeTokenFamily(db, stored.FamilyID)
+        print_status(fmt.Sprintf("Reutilização de refresh token detectada (usuário %d); sessão revogada", stored.UserID))
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reutilizado; sessão encerrada"})
+        return
+    }
+
+    if time.Now().After(stored.ExpiresAt) {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expirado"})
+        return
+    }
+
+    var user User
+    if err := db.Where("id = ?", stored.UserID).First(&user).Error; err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token inválido"})
+        return
+    }
+
+    var pair tokenPair
+    reused := false
+    err := db.Transaction(func(tx *gorm.DB) error {
+        // UPDATE condicional: em requisições concorrentes apenas uma rotaciona
+        result := tx.Model(&RefreshToken{}).
+            Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", stored.ID).
+            Update("used_at", time.Now())
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            reused = true
+            return revokeTokenFamily(tx, stored.FamilyID)
+        }
+
+        var err error
+        pair, err = issueTokenPair(tx, user, stored.FamilyID)
+        return err
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar token"})
+        return
+    }
+    if reused {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reutilizado; sessão encerrada"})
+        return
+    }
+
+    c.JSON(http.StatusOK, tokenResponse(pair, user))
+}
+
+func logoutHandler(c *gin.Context) {
+    familyID := c.GetString("session_id")
+
+    if err := revokeTokenFamily(db, familyID); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao encerrar sessão"})
+        return
+    }
+
+    userID, _ := c.Get("user_id")
+    print_status(fmt.Sprintf("Logout realizado: usuário %d", userID))
+    c.JSON(http.StatusOK, gin.H{"message": "Sessão encerrada com sucesso"})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+    "encoding/json"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+func loginTokens(router *gin.Engine, email string) (string, string) {
+    w := performRequest(router, "POST", "/api/auth/login", "", LoginRequest{
+        Email:    email,
+        Password: "password123",
+    })
+    
+    var response map[string]interface{}
+    json.Unmarshal(w.Body.Bytes(), &response)
+    access, _ := response["token"].(string)
+    refresh, _ := response["refresh_token"].(string)
+    
+    return access, refresh
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Test User", "test@example.com", "")
+    _, refresh := loginTokens(router, "test@example.com")
+    
+    w := performRequest(router, "POST", "/api/auth/refresh", "", RefreshTokenRequest{RefreshToken: refresh})
+    assert.Equal(t, 200, w.Code)
+    
+    var response map[string]interface{}
+    json.Unmarshal(w.Body.Bytes(), &response)
+    newAccess := response["token"].(string)
+    newRefresh := response["refresh_token"].(string)
+    assert.NotEqual(t, refresh, newRefresh)
+    
+    w = performRequest(router, "GET", "/api/travel-requests", newAccess, nil)
+    assert.Equal(t, 200, w.Code)
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Test User", "test@example.com", "")
+    _, refresh := loginTokens(router, "test@example.com")
+    
+    w := performRequest(router, "POST", "/api/auth/refresh", "", RefreshTokenRequest{RefreshToken: refresh})
+    var response map[string]interface{}
+    json.Unmarshal(w.Body.Bytes(), &response)
+    newAccess := response["token"].(string)
+    newRefresh := response["refresh_token"].(string)
+    
+    // Reutilizar o token antigo encerra a sessão inteira
+    w = performRequest(router, "POST", "/api/auth/refresh", "", RefreshTokenRequest{RefreshToken: refresh})
+    assert.Equal(t, 401, w.Code)
+    assert.Contains(t, w.Body.String(), "reutilizado")
+    
+    w = performRequest(router, "POST", "/api/auth/refresh", "", RefreshTokenRequest{RefreshToken: newRefresh})
+    assert.Equal(t, 401, w.Code)
+    
+    w = performRequest(router, "GET", "/api/travel-requests", newAccess, nil)
+    assert.Equal(t, 401, w.Code)
+    assert.Contains(t, w.Body.String(), "Token revogado")
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Test User", "test@example.com", "")
+    access, refresh := loginTokens(router, "test@example.com")
+    otherAccess, _ := loginTokens(router, "test@example.com")
+    
+    w := performRequest(router, "POST", "/api/auth/logout", access, nil)
+    assert.Equal(t, 200, w.Code)
+    
+    w = performRequest(router, "GET", "/api/travel-requests", access, nil)
+    assert.Equal(t, 401, w.Code)
+    
+    w = performRequest(router, "POST", "/api/auth/refresh", "", RefreshTokenRequest{RefreshToken: refresh})
+    assert.Equal(t, 401, w.Code)
+    
+    // Outras sessões do mesmo usuário continuam válidas
+    w = performRequest(router, "GET", "/api/travel-requests", otherAccess, nil)
+    assert.Equal(t, 200, w.Code)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Permissão para um usuário (delegado, ex.: assistente) criar e editar
+// pedidos em nome de outro (titular, o viajante)
+type BookingDelegation struct {
+    ID          uint      `json:"id" gorm:"primaryKey"`
+    PrincipalID uint      `json:"principal_id" gorm:"uniqueIndex:idx_booking_delegations_pair"`
+    Principal   *User     `json:"principal,omitempty" gorm:"foreignKey:PrincipalID"`
+    DelegateID  uint      `json:"delegate_id" gorm:"uniqueIndex:idx_booking_delegations_pair;index"`
+    Delegate    *User     `json:"delegate,omitempty" gorm:"foreignKey:DelegateID"`
+    CreatedByID uint      `json:"created_by_id"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+type BookingDelegationInput struct {
+    DelegateID  uint `json:"delegate_id" binding:"required"`
+    PrincipalID uint `json:"principal_id"` // Apenas admin; padrão: o próprio usuário
+}
+
+// errBookingNotAllowed indica que o usuário não pode reservar para o viajante
+var errBookingNotAllowed = errors.New("Você não tem permissão para criar pedidos em nome deste viajante")
+
+// resolveTraveler carrega o viajante do pedido. Sem travelerID, o viajante é
+// o próprio usuário; para outra pessoa é preciso ser admin ou delegado dela.
+func resolveTraveler(booker User, travelerID uint) (User, error) {
+    if travelerID == 0 || travelerID == booker.ID {
+        return booker, nil
+    }
+
+    var traveler User
+    if err := db.Where("id = ?", travelerID).First(&traveler).Error; err != nil {
+        return traveler, fmt.Errorf("Viajante não encontrado")
+    }
+    if booker.Role == RoleAdmin {
+        return traveler, nil
+    }
+
+    var count int64
+    db.Model(&BookingDelegation{}).Where("principal_id = ? AND delegate_id = ?", traveler.ID, booker.ID).Count(&count)
+    if count == 0 {
+        return traveler, errBookingNotAllowed
+    }
+    return traveler, nil
+}
+
+// respondTravelerError responde 403 para falta de permissão e 400 para viajante inexistente
+func respondTravelerError(c *gin.Context, err error) {
+    status := http.StatusBadRequest
+    if errors.Is(err, errBookingNotAllowed) {
+        status = http.StatusForbidden
+    }
+    c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// listBookingDelegationsHandler lista as delegações em que o usuário é titular
+// ou delegado (admin vê todas)
+func listBookingDelegationsHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    query := db.Preload("Principal").Preload("Delegate").Order("id ASC")
+    if user.Role != RoleAdmin {
+        query = query.Where("principal_id = ? OR delegate_id = ?", user.ID, user.ID)
+    }
+
+    delegations := []BookingDelegation{}
+    if err := query.Find(&delegations).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar delegações"})
+        return
+    }
+
+    c.JSON(http.StatusOK, delegations)
+}
+
+// createBookingDelegationHandler autoriza um delegado a reservar em nome do
+// usuário. Admins podem informar principal_id para configurar qualquer titular.
+func createBookingDelegationHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    var req BookingDelegationInput
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    principalID := user.ID
+    if req.PrincipalID != 0 && req.PrincipalID != user.ID {
+        if user.Role != RoleAdmin {
+            c.JSON(http.StatusForbidden, gin.H{"error": "Apenas administradores podem delegar em nome de outro usuário"})
+            return
+        }
+        principalID = req.PrincipalID
+    }
+    if req.DelegateID == principalID {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "O delegado deve ser outro usuário"})
+        return
+    }
+
+    var principal, delegate User
+    if err := db.Where("id = ?", principalID).First(&principal).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Titular não encontrado"})
+        return
+    }
+    if err := db.Where("id = ?", req.DelegateID).First(&delegate).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Delegado não encontrado"})
+        return
+    }
+
+    var existing BookingDelegation
+    err = db.Where("principal_id = ? AND delegate_id = ?", principal.ID, delegate.ID).First(&existing).Error
+    if err == nil {
+        c.JSON(http.StatusConflict, gin.H{"error": "Delegação já existe"})
+        return
+    }
+    if !errors.Is(err, gorm.ErrRecordNotFound) {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar delegação"})
+        return
+    }
+
+    delegation := BookingDelegation{PrincipalID: principal.ID, DelegateID: delegate.ID, CreatedByID: user.ID}
+    if err := db.Create(&delegation).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar delegação"})
+        return
+    }
+    delegation.Principal = &principal
+    delegation.Delegate = &delegate
+
+    print_status(fmt.Sprintf("%s pode reservar viagens para %s", delegate.Email, principal.Email))
+    c.JSON(http.StatusCreated, delegation)
+}
+
+// deleteBookingDelegationHandler revoga a delegação (titular, delegado ou admin)
+func deleteBookingDelegationHandler(c *gin.Context) {
+    user, err := currentUser(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    var delegation BookingDelegation
+    if err := db.Where("id = ?", c.Param("id")).First(&delegation).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Delegação não encontrada"})
+        return
+    }
+    if user.Role != RoleAdmin && delegation.PrincipalID != user.ID && delegation.DelegateID != user.ID {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Delegação não encontrada"})
+        return
+    }
+
+    if err := db.Delete(&delegation).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover delegação"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Delegação removida com sucesso"})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestBookOnBehalfRequiresDelegation(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    assistant := registerAndLogin(router, "Assistant", "assistant@example.com", "")
+    
+    trip := CreateTravelRequest{TravelerID: 3, Destination: "Recife", DepartureDate: daysFromNow(10), ReturnDate: daysFromNow(12)}
+    w := performRequest(router, "POST", "/api/travel-requests", assistant, trip)
+    assert.Equal(t, 403, w.Code)
+    
+    trip.TravelerID = 99
+    w = performRequest(router, "POST", "/api/travel-requests", assistant, trip)
+    assert.Equal(t, 400, w.Code)
+    assert.Contains(t, w.Body.String(), "Viajante não encontrado")
+    
+    w = performRequest(router, "POST", "/api/booking-delegations", tokens["requester"], BookingDelegationInput{DelegateID: 6})
+    assert.Equal(t, 201, w.Code)
+    
+    trip.TravelerID = 3
+    w = performRequest(router, "POST", "/api/travel-requests", assistant, trip)
+    assert.Equal(t, 201, w.Code)
+    
+    var created TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &created)
+    assert.Equal(t, uint(3), created.TravelerID)
+    assert.Equal(t, uint(6), created.UserID, "quem reservou continua responsável")
+    assert.Equal(t, "Requester", created.RequesterName, "nome derivado do viajante")
+    
+    step, _ := currentApprovalStep(created.ID)
+    assert.Equal(t, uint(2), *step.ApproverID, "cadeia segue o gestor do viajante")
+    
+    // O viajante vê e pode editar o pedido feito em seu nome
+    w = performRequest(router, "GET", fmt.Sprintf("/api/travel-requests/%d", created.ID), tokens["requester"], nil)
+    assert.Equal(t, 200, w.Code)
+    
+    w = performRequest(router, "GET", "/api/travel-requests?traveler_id=3", assistant, nil)
+    var list listResponse
+    json.Unmarshal(w.Body.Bytes(), &list)
+    assert.Equal(t, int64(1), list.Pagination.Total)
+}
+
+func TestEditCanChangeTraveler(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    id := createTestTravelRequest(router, tokens["admin"], "Recife")
+    path := fmt.Sprintf("/api/travel-requests/%d", id)
+    
+    update := UpdateTravelRequest{TravelerID: 3, Destination: "Recife", DepartureDate: "2025-08-15", ReturnDate: "2025-08-20"}
+    w := performConditional(router, "PUT", path, tokens["admin"], "*", update)
+    assert.Equal(t, 200, w.Code)
+    
+    var updated TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &updated)
+    assert.Equal(t, uint(3), updated.TravelerID)
+    assert.Equal(t, "Requester", updated.RequesterName)
+    
+    // Sem traveler_id o viajante é mantido
+    w = performMergePatch(router, path, tokens["requester"], "*", `{"destination": "Natal"}`)
+    assert.Equal(t, 200, w.Code)
+    var patched TravelRequest
+    json.Unmarshal(w.Body.Bytes(), &patched)
+    assert.Equal(t, uint(3), patched.TravelerID)
+    
+    w = performMergePatch(router, path, tokens["requester"], "*", `{"traveler_id": 4}`)
+    assert.Equal(t, 403, w.Code, "o viajante não é delegado de outra pessoa")
+}
+
+func TestBookingDelegationManagement(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    w := performRequest(router, "POST", "/api/booking-delegations", tokens["requester"], BookingDelegationInput{DelegateID: 3})
+    assert.Equal(t, 400, w.Code)
+    
+    w = performRequest(router, "POST", "/api/booking-delegations", tokens["requester"], BookingDelegationInput{DelegateID: 99})
+    assert.Equal(t, 404, w.Code)
+    
+    w = performRequest(router, "POST", "/api/booking-delegations", tokens["requester"], BookingDelegationInput{DelegateID: 4, PrincipalID: 5})
+    assert.Equal(t, 403, w.Code)
+    
+    w = performRequest(router, "POST", "/api/booking-delegations", tokens["admin"], BookingDelegationInput{DelegateID: 4, PrincipalID: 5})
+    assert.Equal(t, 201, w.Code)
+    
+    w = performRequest(router, "POST", "/api/booking-delegations", tokens["requester"], BookingDelegationInput{DelegateID: 4})
+    assert.Equal(t, 201, w.Code)
+    var delegation BookingDelegation
+    json.Unmarshal(w.Body.Bytes(), &delegation)
+    assert.Equal(t, uint(3), delegation.PrincipalID)
+    
+    w = performRequest(router, "POST", "/api/booking-delegations", tokens["requester"], BookingDelegationInput{DelegateID: 4})
+    assert.Equal(t, 409, w.Code)
+    
+    var delegations []BookingDelegation
+    w = performRequest(router, "GET", "/api/booking-delegations", tokens["finance"], nil)
+    json.Unmarshal(w.Body.Bytes(), &delegations)
+    assert.Len(t, delegations, 2, "delegado vê as duas delegações")
+    
+    w = performRequest(router, "DELETE", fmt.Sprintf("/api/booking-delegations/%d", delegation.ID), tokens["manager"], nil)
+    assert.Equal(t, 404, w.Code)
+    
+    w = performRequest(router, "DELETE", fmt.Sprintf("/api/booking-delegations/%d", delegation.ID), tokens["finance"], nil)
+    assert.Equal(t, 200, w.Code)
+    
+    var remaining []BookingDelegation
+    w = performRequest(router, "GET", "/api/booking-delegations", tokens["requester"], nil)
+    json.Unmarshal(w.Body.Bytes(), &remaining)
+    assert.Empty(t, remaining)
+}
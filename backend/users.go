@@ -0,0 +1,134 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+)
+
+type UpdateRoleRequest struct {
+    Role string `json:"role" binding:"required"`
+}
+
+type UpdateDepartmentRequest struct {
+    Department string `json:"department"`
+}
+
+type UpdateManagerRequest struct {
+    ManagerID *uint `json:"manager_id"` // null remove o gestor
+}
+
+func listUsersHandler(c *gin.Context) {
+    var users []User
+    db.Order("id ASC").Find(&users)
+
+    if users == nil {
+        users = []User{}
+    }
+
+    c.JSON(http.StatusOK, users)
+}
+
+func updateUserRoleHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
+    id := c.Param("id")
+
+    var user User
+    if err := db.Where("id = ?", id).First(&user).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    var req UpdateRoleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if !validRoles[req.Role] {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Papel inválido. Use: requester, approver, manager, finance, director ou admin"})
+        return
+    }
+
+    // Evita que o administrador remova o próprio acesso
+    if user.ID == userID.(uint) && req.Role != RoleAdmin {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Você não pode remover seu próprio papel de administrador"})
+        return
+    }
+
+    oldRole := user.Role
+    if err := db.Model(&user).Update("role", req.Role).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar papel"})
+        return
+    }
+
+    print_status(fmt.Sprintf("Papel atualizado: %s %s -> %s", user.Email, oldRole, req.Role))
+    c.JSON(http.StatusOK, user)
+}
+
+func updateUserDepartmentHandler(c *gin.Context) {
+    id := c.Param("id")
+
+    var user User
+    if err := db.Where("id = ?", id).First(&user).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    var req UpdateDepartmentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := db.Model(&user).Update("department", strings.TrimSpace(req.Department)).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar equipe"})
+        return
+    }
+
+    print_status(fmt.Sprintf("Equipe atualizada: %s -> %q", user.Email, user.Department))
+    c.JSON(http.StatusOK, user)
+}
+
+func updateUserManagerHandler(c *gin.Context) {
+    id := c.Param("id")
+
+    var user User
+    if err := db.Where("id = ?", id).First(&user).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+        return
+    }
+
+    var req UpdateManagerRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if req.ManagerID != nil {
+        if *req.ManagerID == user.ID {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Usuário não pode ser o próprio gestor"})
+            return
+        }
+
+        var manager User
+        if err := db.Where("id = ?", *req.ManagerID).First(&manager).Error; err != nil {
+            c.JSON(http.StatusNotFound, gin.H{"error": "Gestor não encontrado"})
+            return
+        }
+    }
+
+    if err := db.Model(&user).Update("manager_id", req.ManagerID).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar gestor"})
+        return
+    }
+
+    if req.ManagerID != nil {
+        print_status(fmt.Sprintf("Gestor atualizado: %s -> usuário %d", user.Email, *req.ManagerID))
+    } else {
+        print_status(fmt.Sprintf("Gestor removido: %s", user.Email))
+    }
+    c.JSON(http.StatusOK, user)
+}
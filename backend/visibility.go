@@ -0,0 +1,110 @@
+package main
+
+import (
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Compartilhamento explícito de um pedido com outro usuário (somente leitura)
+type TravelRequestShare struct {
+    ID              uint      `json:"id" gorm:"primaryKey"`
+    TravelRequestID uint      `json:"travel_request_id" gorm:"uniqueIndex:idx_share_request_user"`
+    UserID          uint      `json:"user_id" gorm:"uniqueIndex:idx_share_request_user"`
+    CreatedAt       time.Time `json:"created_at"`
+}
+
+// currentUser carrega o usuário autenticado a partir do "user_id" do contexto
+func currentUser(c *gin.Context) (User, error) {
+    var user User
+    userID, _ := c.Get("user_id")
+    err := db.Where("id = ?", userID).First(&user).Error
+    return user, err
+}
+
+// visibleTravelRequests aplica as regras de visibilidade por linha:
+// dono do pedido, usuários com quem foi compartilhado, aprovadores da
+// mesma equipe do solicitante, gestor direto, aprovadores designados em
+// alguma etapa da cadeia de aprovação, substitutos de aprovadores ausentes
+// e administradores (que veem tudo).
+func visibleTravelRequests(c *gin.Context) *gorm.DB {
+    user, err := currentUser(c)
+    if err != nil {
+        // Usuário removido: não enxerga nenhum pedido
+        return db.Model(&TravelRequest{}).Where("1 = 0")
+    }
+
+    return visibleTravelRequestsFor(user)
+}
+
+// visibleTravelRequestsFor aplica as mesmas regras para um usuário já carregado
+func visibleTravelRequestsFor(user User) *gorm.DB {
+    query := db.Model(&TravelRequest{})
+
+    if user.Role == RoleAdmin {
+        return query
+    }
+
+    visibility := travelRequestVisibility(user)
+
+    // Substitutos veem os pedidos que aguardam aprovação dos aprovadores
+    // ausentes, exceto os do próprio aprovador
+    delegators, _ := activeDelegators(db, user.ID)
+    for _, approver := range delegators {
+        awaiting := db.Model(&TravelRequest{}).Select("travel_requests.id").
+            Where("travel_requests.status = ?", StatusRequested).
+            Where("travel_requests.user_id <> ? AND travel_requests.created_by_id <> ? AND travel_requests.traveler_id <> ?", approver.ID, approver.ID, approver.ID)
+        if approver.Role != RoleAdmin {
+            awaiting = awaiting.Where(travelRequestVisibility(approver))
+        }
+        visibility = visibility.Or("travel_requests.id IN (?)", awaiting)
+    }
+
+    return query.Where(visibility)
+}
+
+// travelRequestVisibility monta as condições de visibilidade do próprio
+// usuário (sem substituições), para um usuário que não é admin
+func travelRequestVisibility(user User) *gorm.DB {
+    visibility := db.Where("travel_requests.user_id = ?", user.ID).
+        Or("travel_requests.created_by_id = ?", user.ID).
+        Or("travel_requests.traveler_id = ?", user.ID).
+        Or("travel_requests.id IN (?)", db.Model(&TravelRequestShare{}).Select("travel_request_id").Where("user_id = ?", user.ID)).
+        Or("travel_requests.traveler_id IN (?)", db.Model(&User{}).Select("id").Where("manager_id = ?", user.ID))
+
+    // Etapas com aprovador específico ou de papéis dedicados (financeiro, diretoria)
+    // e as já decididas pelo usuário (inclusive como substituto).
+    // Etapas abertas a qualquer aprovador seguem a regra de equipe abaixo.
+    steps := db.Model(&ApprovalStep{}).Select("travel_request_id").Where("approver_id = ? OR decided_by_id = ?", user.ID, user.ID)
+    if user.Role != RoleRequester && user.Role != RoleApprover {
+        steps = steps.Or("approver_role = ?", user.Role)
+    }
+    visibility = visibility.Or("travel_requests.id IN (?)", steps)
+
+    if user.Department != "" && hasRole(user.Role, approverRoles...) {
+        visibility = visibility.Or("travel_requests.traveler_id IN (?)", db.Model(&User{}).Select("id").Where("department = ?", user.Department))
+    }
+
+    return visibility
+}
+
+// findVisibleTravelRequest busca um pedido pelo ID respeitando a visibilidade.
+// Pedidos invisíveis se comportam como inexistentes (gorm.ErrRecordNotFound).
+func findVisibleTravelRequest(c *gin.Context, id string) (TravelRequest, error) {
+    var request TravelRequest
+    err := withTravelRequestDetails(visibleTravelRequests(c)).Where("travel_requests.id = ?", id).First(&request).Error
+    return request, err
+}
+
+// withTravelRequestDetails carrega os itens de custo e o itinerário do pedido
+func withTravelRequestDetails(query *gorm.DB) *gorm.DB {
+    return query.Preload("CostItems").Preload("Legs", func(tx *gorm.DB) *gorm.DB {
+        return tx.Order("position ASC")
+    })
+}
+
+// isRequestOwner indica se o usuário é dono ou criador do pedido
+func isRequestOwner(request TravelRequest, userID uint) bool {
+    return request.UserID == userID || request.CreatedByID == userID || request.TravelerID == userID
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+    "fmt"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestListOnlyShowsVisibleRequests(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    adminToken := registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    aliceToken := registerAndLogin(router, "Alice", "alice@example.com", "")
+    bobToken := registerAndLogin(router, "Bob", "bob@example.com", "")
+    
+    createTestTravelRequest(router, aliceToken, "Natal")
+    createTestTravelRequest(router, bobToken, "Manaus")
+    
+    w := performRequest(router, "GET", "/api/travel-requests", aliceToken, nil)
+    assert.Contains(t, w.Body.String(), "Natal")
+    assert.NotContains(t, w.Body.String(), "Manaus")
+    
+    w = performRequest(router, "GET", "/api/travel-requests", adminToken, nil)
+    assert.Contains(t, w.Body.String(), "Natal")
+    assert.Contains(t, w.Body.String(), "Manaus")
+}
+
+func TestInvisibleRequestReturnsNotFound(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    aliceToken := registerAndLogin(router, "Alice", "alice@example.com", "")
+    bobToken := registerAndLogin(router, "Bob", "bob@example.com", RoleApprover)
+    
+    id := createTestTravelRequest(router, aliceToken, "Natal")
+    path := "/api/travel-requests/" + fmt.Sprint(id)
+    
+    w := performRequest(router, "GET", path, bobToken, nil)
+    assert.Equal(t, 404, w.Code)
+    
+    w = performConditional(router, "PUT", path+"/status", bobToken, "*", UpdateStatusRequest{Status: "aprovado"})
+    assert.Equal(t, 404, w.Code)
+    
+    w = performConditional(router, "DELETE", path, bobToken, "*", nil)
+    assert.Equal(t, 404, w.Code)
+}
+
+func TestTeamApproverSeesTeamRequests(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    aliceToken := registerAndLogin(router, "Alice", "alice@example.com", "")
+    bobToken := registerAndLogin(router, "Bob", "bob@example.com", RoleApprover)
+    carolToken := registerAndLogin(router, "Carol", "carol@example.com", "")
+    db.Model(&User{}).Where("email IN ?", []string{"alice@example.com", "bob@example.com", "carol@example.com"}).Update("department", "Vendas")
+    
+    id := createTestTravelRequest(router, aliceToken, "Natal")
+    path := "/api/travel-requests/" + fmt.Sprint(id)
+    
+    // Aprovador da mesma equipe enxerga o pedido
+    w := performRequest(router, "GET", path, bobToken, nil)
+    assert.Equal(t, 200, w.Code)
+    
+    // Colega sem papel de aprovador não enxerga
+    w = performRequest(router, "GET", path, carolToken, nil)
+    assert.Equal(t, 404, w.Code)
+}
+
+func TestSharedUserCanViewButNotCancel(t *testing.T) {
+    setupTestDB()
+    router := setupTestRouter()
+    
+    registerAndLogin(router, "Admin", "admin@example.com", RoleAdmin)
+    aliceToken := registerAndLogin(router, "Alice", "alice@example.com", "")
+    bobToken := registerAndLogin(router, "Bob", "bob@example.com", "")
+    
+    var bob User
+    db.Where("email = ?", "bob@example.com").First(&bob)
+    
+    id := createTestTravelRequest(router, aliceToken, "Natal")
+    path := "/api/travel-requests/" + fmt.Sprint(id)
+    
+    // Somente o dono pode compartilhar
+    w := performRequest(router, "POST", path+"/shares", bobToken, ShareTravelRequest{UserID: bob.ID})
+    assert.Equal(t, 404, w.Code)
+    
+    w = performRequest(router, "POST", path+"/shares", aliceToken, ShareTravelRequest{UserID: bob.ID})
+    assert.Equal(t, 201, w.Code)
+    
+    w = performRequest(router, "GET", path, bobToken, nil)
+    assert.Equal(t, 200, w.Code)
+    
+    w = performConditional(router, "DELETE", path, bobToken, "*", nil)
+    assert.Equal(t, 403, w.Code)
+    
+    w = performConditional(router, "DELETE", path+"/shares/"+fmt.Sprint(bob.ID), aliceToken, "*", nil)
+    assert.Equal(t, 200, w.Code)
+    
+    w = performRequest(router, "GET", path, bobToken, nil)
+    assert.Equal(t, 404, w.Code)
+}
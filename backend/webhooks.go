@@ -0,0 +1,472 @@
+package main
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "net/url"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Situação de uma entrega de webhook
+const (
+    DeliveryPending   = "pending"
+    DeliveryDelivered = "delivered"
+    DeliveryFailed    = "failed" // tentativas esgotadas
+)
+
+const (
+    webhookSignatureHeader = "X-Webhook-Signature"
+    webhookResponseLimit   = 1024 // bytes da resposta guardados no log de entregas
+)
+
+var webhookEventTypes = map[string]bool{
+    EventRequestCreated:       true,
+    EventRequestStatusChanged: true,
+    EventRequestCancelled:     true,
+    EventRequestReassigned:    true,
+    EventApprovalRequested:    true,
+}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// Assinatura de webhook de um sistema externo (financeiro, RH...)
+type WebhookSubscription struct {
+    ID          uint      `json:"id" gorm:"primaryKey"`
+    URL         string    `json:"url"`
+    Events      []string  `json:"events" gorm:"serializer:json"` // vazio recebe todos os eventos
+    Secret      string    `json:"-"`
+    Active      bool      `json:"active" gorm:"default:true"`
+    CreatedByID uint      `json:"created_by_id"`
+    CreatedAt   time.Time `json:"created_at"`
+    UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Registro de cada entrega de um evento a uma assinatura
+type WebhookDelivery struct {
+    ID             uint       `json:"id" gorm:"primaryKey"`
+    SubscriptionID uint       `json:"subscription_id" gorm:"index"`
+    EventID        uint       `json:"event_id"` // ID do evento no outbox
+    EventType      string     `json:"event_type"`
+    Payload        string     `json:"payload" gorm:"type:text"`
+    Status         string     `json:"status" gorm:"default:'pending'"`
+    Attempts       int        `json:"attempts"`
+    NextAttemptAt  time.Time  `json:"next_attempt_at"`
+    ResponseStatus int        `json:"response_status"`
+    ResponseBody   string     `json:"response_body"`
+    LastError      string     `json:"last_error"`
+    DeliveredAt    *time.Time `json:"delivered_at"`
+    CreatedAt      time.Time  `json:"created_at"`
+}
+
+type CreateWebhookSubscription struct {
+    URL    string   `json:"url" binding:"required"`
+    Events []string `json:"events"`
+    Secret string   `json:"secret"` // gerado automaticamente se vazio
+}
+
+type UpdateWebhookSubscription struct {
+    URL    string   `json:"url" binding:"required"`
+    Events []string `json:"events"`
+    Active *bool    `json:"active"`
+    Secret string   `json:"secret"` // vazio mantém o segredo atual
+}
+
+// Formato público de um evento, enviado a webhooks e ao stream SSE. Não
+// inclui os destinatários, que são internos.
+type eventPayload struct {
+    ID         uint      `json:"id"`
+    Type       string    `json:"type"`
+    OccurredAt time.Time `json:"occurred_at"`
+    Data       eventData `json:"data"`
+}
+
+type eventData struct {
+    Request    TravelRequest `json:"request"`
+    FromStatus string        `json:"from_status,omitempty"`
+    ActorID    uint          `json:"actor_id"`
+    Reason     string        `json:"reason,omitempty"`
+    StepName   string        `json:"step_name,omitempty"`
+}
+
+func newEventPayload(event TravelRequestEvent) eventPayload {
+    return eventPayload{
+        ID:         event.ID,
+        Type:       event.Type,
+        OccurredAt: event.OccurredAt,
+        Data: eventData{
+            Request:    event.Request,
+            FromStatus: event.FromStatus,
+            ActorID:    event.ActorID,
+            Reason:     event.Reason,
+            StepName:   event.StepName,
+        },
+    }
+}
+
+func (s WebhookSubscription) accepts(eventType string) bool {
+    if len(s.Events) == 0 {
+        return true
+    }
+    for _, e := range s.Events {
+        if e == eventType {
+            return true
+        }
+    }
+    return false
+}
+
+// signWebhook calcula o header de assinatura "t=<unix>,v1=<hex>". O HMAC-SHA256
+// cobre "<t>.<corpo>", o que impede reaproveitar um corpo com outro timestamp.
+func signWebhook(secret string, timestamp time.Time, body []byte) string {
+    t := strconv.FormatInt(timestamp.Unix(), 10)
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(t + "."))
+    mac.Write(body)
+    return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// subscriptionsNotifier é o canal do outbox que agenda uma entrega para cada
+// assinatura ativa interessada no evento
+type subscriptionsNotifier struct{}
+
+func (subscriptionsNotifier) Name() string { return "webhooks" }
+
+func (subscriptionsNotifier) Notify(event TravelRequestEvent, recipients []Recipient) error {
+    var subscriptions []WebhookSubscription
+    if err := db.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+        return err
+    }
+
+    body, err := json.Marshal(newEventPayload(event))
+    if err != nil {
+        return err
+    }
+
+    deliveries := []WebhookDelivery{}
+    for _, subscription := range subscriptions {
+        if !subscription.accepts(event.Type) {
+            continue
+        }
+        deliveries = append(deliveries, WebhookDelivery{
+            SubscriptionID: subscription.ID,
+            EventID:        event.ID,
+            EventType:      event.Type,
+            Payload:        string(body),
+            Status:         DeliveryPending,
+            NextAttemptAt:  time.Now(),
+        })
+    }
+
+    if len(deliveries) == 0 {
+        return nil
+    }
+    return db.Create(&deliveries).Error
+}
+
+// dispatchWebhookDeliveries envia as entregas vencidas. Cada assinatura tem
+// suas próprias tentativas: um assinante fora do ar não atrasa os outros.
+func dispatchWebhookDeliveries(now time.Time) (int, error) {
+    var candidates []WebhookDelivery
+    err := db.Where("status = ? AND next_attempt_at <= ?", DeliveryPending, now).
+        Order("id ASC").Limit(outboxBatchSize).Find(&candidates).Error
+    if err != nil {
+        return 0, err
+    }
+
+    processed := 0
+    for _, delivery := range candidates {
+        // Mesmo esquema de reivindicação do outbox
+        leaseUntil := now.Add(outboxClaimLease)
+        result := db.Model(&WebhookDelivery{}).
+            Where("id = ? AND status = ? AND attempts = ?", delivery.ID, DeliveryPending, delivery.Attempts).
+            Updates(map[string]interface{}{"attempts": delivery.Attempts + 1, "next_attempt_at": leaseUntil})
+        if result.Error != nil {
+            return processed, result.Error
+        }
+        if result.RowsAffected == 0 {
+            continue
+        }
+        delivery.Attempts++
+        processed++
+
+        // Assinatura removida ou desativada: a entrega é encerrada e pode ser
+        // reenviada manualmente depois
+        var subscription WebhookSubscription
+        err := db.Where("id = ?", delivery.SubscriptionID).First(&subscription).Error
+        if err != nil || !subscription.Active {
+            delivery.Status = DeliveryFailed
+            delivery.LastError = "assinatura inativa"
+            if err := db.Model(&delivery).Select("status", "last_error").Updates(&delivery).Error; err != nil {
+                return processed, err
+            }
+            continue
+        }
+
+        deliveryErr := sendWebhook(subscription, &delivery)
+        completeWebhookDelivery(&delivery, deliveryErr, time.Now())
+        if err := db.Model(&delivery).
+            Select("status", "next_attempt_at", "response_status", "response_body", "last_error", "delivered_at").
+            Updates(&delivery).Error; err != nil {
+            return processed, err
+        }
+    }
+    return processed, nil
+}
+
+// sendWebhook faz o POST assinado e guarda o resultado na entrega
+func sendWebhook(subscription WebhookSubscription, delivery *WebhookDelivery) error {
+    body := []byte(delivery.Payload)
+    req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("User-Agent", "travel-requests-webhooks/1.0")
+    req.Header.Set("X-Webhook-Event", delivery.EventType)
+    req.Header.Set("X-Webhook-Event-ID", strconv.FormatUint(uint64(delivery.EventID), 10))
+    req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+    req.Header.Set(webhookSignatureHeader, signWebhook(subscription.Secret, time.Now(), body))
+
+    resp, err := webhookClient.Do(req)
+    if err != nil {
+        delivery.ResponseStatus = 0
+        delivery.ResponseBody = ""
+        return err
+    }
+    defer resp.Body.Close()
+
+    response, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+    delivery.ResponseStatus = resp.StatusCode
+    delivery.ResponseBody = string(response)
+
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return fmt.Errorf("assinante respondeu %d", resp.StatusCode)
+    }
+    return nil
+}
+
+// completeWebhookDelivery aplica a política de novas tentativas do outbox
+func completeWebhookDelivery(delivery *WebhookDelivery, deliveryErr error, now time.Time) {
+    switch {
+    case deliveryErr == nil:
+        delivery.Status = DeliveryDelivered
+        delivery.DeliveredAt = &now
+        delivery.LastError = ""
+    case delivery.Attempts >= outboxMaxAttempts:
+        delivery.Status = DeliveryFailed
+        delivery.LastError = deliveryErr.Error()
+        log.Printf("Entrega de webhook %d desistida após %d tentativas: %v", delivery.ID, delivery.Attempts, deliveryErr)
+    default:
+        delivery.LastError = deliveryErr.Error()
+        delivery.NextAttemptAt = now.Add(outboxBackoff(delivery.Attempts))
+    }
+}
+
+// validateWebhookSubscription confere URL e filtro de eventos
+func validateWebhookSubscription(rawURL string, events []string) string {
+    parsed, err := url.Parse(rawURL)
+    if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+        return "URL inválida: use http:// ou https://"
+    }
+    for _, e := range events {
+        if !webhookEventTypes[e] {
+            return "Evento inválido: " + e
+        }
+    }
+    return ""
+}
+
+func listWebhookSubscriptionsHandler(c *gin.Context) {
+    subscriptions := []WebhookSubscription{}
+    db.Order("id ASC").Find(&subscriptions)
+
+    c.JSON(http.StatusOK, subscriptions)
+}
+
+func createWebhookSubscriptionHandler(c *gin.Context) {
+    userID, _ := c.Get("user_id")
+
+    var req CreateWebhookSubscription
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if message := validateWebhookSubscription(req.URL, req.Events); message != "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": message})
+        return
+    }
+
+    secret := req.Secret
+    if secret == "" {
+        generated, err := randomToken(32)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar segredo"})
+            return
+        }
+        secret = generated
+    }
+
+    subscription := WebhookSubscription{
+        URL:         req.URL,
+        Events:      req.Events,
+        Secret:      secret,
+        Active:      true,
+        CreatedByID: userID.(uint),
+    }
+    if subscription.Events == nil {
+        subscription.Events = []string{}
+    }
+
+    if err := db.Create(&subscription).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar assinatura"})
+        return
+    }
+
+    print_status(fmt.Sprintf("Assinatura de webhook %d criada para %s", subscription.ID, subscription.URL))
+
+    // O segredo só é exibido na criação
+    c.JSON(http.StatusCreated, gin.H{
+        "subscription": subscription,
+        "secret":       secret,
+    })
+}
+
+func findWebhookSubscription(c *gin.Context) (WebhookSubscription, bool) {
+    var subscription WebhookSubscription
+    if err := db.Where("id = ?", c.Param("id")).First(&subscription).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Assinatura não encontrada"})
+        return subscription, false
+    }
+    return subscription, true
+}
+
+func getWebhookSubscriptionHandler(c *gin.Context) {
+    subscription, ok := findWebhookSubscription(c)
+    if !ok {
+        return
+    }
+
+    c.JSON(http.StatusOK, subscription)
+}
+
+func updateWebhookSubscriptionHandler(c *gin.Context) {
+    subscription, ok := findWebhookSubscription(c)
+    if !ok {
+        return
+    }
+
+    var req UpdateWebhookSubscription
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if message := validateWebhookSubscription(req.URL, req.Events); message != "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": message})
+        return
+    }
+
+    subscription.URL = req.URL
+    subscription.Events = req.Events
+    if subscription.Events == nil {
+        subscription.Events = []string{}
+    }
+    if req.Active != nil {
+        subscription.Active = *req.Active
+    }
+    if req.Secret != "" {
+        subscription.Secret = req.Secret
+    }
+
+    if err := db.Model(&subscription).Select("url", "events", "active", "secret").Updates(&subscription).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar assinatura"})
+        return
+    }
+
+    c.JSON(http.StatusOK, subscription)
+}
+
+func deleteWebhookSubscriptionHandler(c *gin.Context) {
+    subscription, ok := findWebhookSubscription(c)
+    if !ok {
+        return
+    }
+
+    err := db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("subscription_id = ?", subscription.ID).Delete(&WebhookDelivery{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&subscription).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover assinatura"})
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+// listWebhookDeliveriesHandler mostra o log de entregas da assinatura, das
+// mais recentes para as mais antigas, opcionalmente filtrado por status
+func listWebhookDeliveriesHandler(c *gin.Context) {
+    subscription, ok := findWebhookSubscription(c)
+    if !ok {
+        return
+    }
+
+    query := db.Where("subscription_id = ?", subscription.ID)
+    if status := c.Query("status"); status != "" {
+        query = query.Where("status = ?", status)
+    }
+
+    deliveries := []WebhookDelivery{}
+    if err := query.Order("id DESC").Limit(maxPageLimit).Find(&deliveries).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar entregas"})
+        return
+    }
+
+    c.JSON(http.StatusOK, deliveries)
+}
+
+// redeliverWebhookHandler agenda uma nova entrega com o mesmo corpo. A
+// entrega original permanece no log.
+func redeliverWebhookHandler(c *gin.Context) {
+    subscription, ok := findWebhookSubscription(c)
+    if !ok {
+        return
+    }
+
+    var original WebhookDelivery
+    err := db.Where("id = ? AND subscription_id = ?", c.Param("delivery_id"), subscription.ID).First(&original).Error
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Entrega não encontrada"})
+        return
+    }
+
+    delivery := WebhookDelivery{
+        SubscriptionID: subscription.ID,
+        EventID:        original.EventID,
+        EventType:      original.EventType,
+        Payload:        original.Payload,
+        Status:         DeliveryPending,
+        NextAttemptAt:  time.Now(),
+    }
+    if err := db.Create(&delivery).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao agendar nova entrega"})
+        return
+    }
+
+    c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+type receivedWebhook struct {
+    Header http.Header
+    Body   []byte
+}
+
+// startWebhookReceiver responde com os status informados, em ordem; depois do
+// último, repete-o
+func startWebhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []receivedWebhook) {
+    var mu sync.Mutex
+    var received []receivedWebhook
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        mu.Lock()
+        received = append(received, receivedWebhook{Header: r.Header.Clone(), Body: body})
+        status := statuses[len(statuses)-1]
+        if len(received) <= len(statuses) {
+            status = statuses[len(received)-1]
+        }
+        mu.Unlock()
+        w.WriteHeader(status)
+        fmt.Fprint(w, "ok")
+    }))
+    t.Cleanup(server.Close)
+    
+    return server, func() []receivedWebhook {
+        mu.Lock()
+        defer mu.Unlock()
+        return append([]receivedWebhook(nil), received...)
+    }
+}
+
+func createWebhookSubscription(t *testing.T, router *gin.Engine, token string, body CreateWebhookSubscription) (WebhookSubscription, string) {
+    w := performRequest(router, "POST", "/api/webhooks", token, body)
+    assert.Equal(t, 201, w.Code)
+    
+    var response struct {
+        Subscription WebhookSubscription `json:"subscription"`
+        Secret       string              `json:"secret"`
+    }
+    json.Unmarshal(w.Body.Bytes(), &response)
+    
+    return response.Subscription, response.Secret
+}
+
+func TestWebhookSubscriptionManagement(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    
+    w := performRequest(router, "POST", "/api/webhooks", tokens["requester"], CreateWebhookSubscription{URL: "https://rh.example.com/hooks"})
+    assert.Equal(t, 403, w.Code)
+    
+    w = performRequest(router, "POST", "/api/webhooks", tokens["admin"], CreateWebhookSubscription{URL: "ftp://rh.example.com"})
+    assert.Equal(t, 400, w.Code)
+    
+    w = performRequest(router, "POST", "/api/webhooks", tokens["admin"], CreateWebhookSubscription{
+        URL: "https://rh.example.com/hooks", Events: []string{"request.deleted"},
+    })
+    assert.Equal(t, 400, w.Code)
+    
+    subscription, secret := createWebhookSubscription(t, router, tokens["admin"], CreateWebhookSubscription{
+        URL: "https://rh.example.com/hooks", Events: []string{EventRequestCancelled},
+    })
+    assert.NotEmpty(t, secret)
+    assert.True(t, subscription.Active)
+    
+    w = performRequest(router, "GET", fmt.Sprintf("/api/webhooks/%d", subscription.ID), tokens["admin"], nil)
+    assert.Equal(t, 200, w.Code)
+    assert.NotContains(t, w.Body.String(), secret, "o segredo só aparece na criação")
+    
+    active := false
+    w = performRequest(router, "PUT", fmt.Sprintf("/api/webhooks/%d", subscription.ID), tokens["admin"], UpdateWebhookSubscription{
+        URL: "https://rh.example.com/v2/hooks", Events: []string{EventRequestStatusChanged}, Active: &active,
+    })
+    assert.Equal(t, 200, w.Code)
+    
+    var stored WebhookSubscription
+    db.First(&stored, subscription.ID)
+    assert.Equal(t, "https://rh.example.com/v2/hooks", stored.URL)
+    assert.Equal(t, []string{EventRequestStatusChanged}, stored.Events)
+    assert.False(t, stored.Active)
+    assert.Equal(t, secret, stored.Secret)
+    
+    w = performConditional(router, "DELETE", fmt.Sprintf("/api/webhooks/%d", subscription.ID), tokens["admin"], "*", nil)
+    assert.Equal(t, 204, w.Code)
+    w = performRequest(router, "GET", fmt.Sprintf("/api/webhooks/%d", subscription.ID), tokens["admin"], nil)
+    assert.Equal(t, 404, w.Code)
+}
+
+func TestWebhookDeliveryIsSignedAndFiltered(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    useNotifiers(t, subscriptionsNotifier{})
+    receiver, received := startWebhookReceiver(t, 200)
+    
+    _, secret := createWebhookSubscription(t, router, tokens["admin"], CreateWebhookSubscription{
+        URL: receiver.URL, Events: []string{EventRequestStatusChanged},
+    })
+    
+    requestID := createTestTravelRequest(router, tokens["requester"], "Recife")
+    w := performConditional(router, "POST", fmt.Sprintf("/api/travel-requests/%d/approvals/1/approve", requestID), tokens["manager"], "*", nil)
+    assert.Equal(t, 200, w.Code)
+    
+    flushOutbox(t)
+    processed, err := dispatchWebhookDeliveries(time.Now())
+    assert.NoError(t, err)
+    assert.Equal(t, 1, processed, "request.created não está no filtro da assinatura")
+    
+    calls := received()
+    assert.Len(t, calls, 1)
+    call := calls[0]
+    assert.Equal(t, EventRequestStatusChanged, call.Header.Get("X-Webhook-Event"))
+    
+    // Verificação como o assinante faria
+    parts := strings.Split(call.Header.Get(webhookSignatureHeader), ",")
+    assert.Len(t, parts, 2)
+    timestamp := strings.TrimPrefix(parts[0], "t=")
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(timestamp + "."))
+    mac.Write(call.Body)
+    assert.Equal(t, "v1="+hex.EncodeToString(mac.Sum(nil)), parts[1])
+    
+    var payload eventPayload
+    json.Unmarshal(call.Body, &payload)
+    assert.Equal(t, EventRequestStatusChanged, payload.Type)
+    assert.Equal(t, StatusApproved, payload.Data.Request.Status)
+    assert.Equal(t, StatusRequested, payload.Data.FromStatus)
+    assert.NotZero(t, payload.ID)
+}
+
+func TestWebhookRetriesAndRedelivery(t *testing.T) {
+    tokens, router := setupApprovalUsers()
+    useNotifiers(t, subscriptionsNotifier{})
+    receiver, received := startWebhookReceiver(t, 500, 200)
+    
+    subscription, _ := createWebhookSubscription(t, router, tokens["admin"], CreateWebhookSubscription{
+        URL:    receiver.URL,
+        Events: []string{EventRequestCreated},
+    })
+    createTestTravelRequest(router, tokens["requester"], "Recife")
+    flushOutbox(t)
+    
+    now := time.Now()
+    dispatchWebhookDeliveries(now)
+    
+    var delivery WebhookDelivery
+    db.First(&delivery)
+    assert.Equal(t, DeliveryPending, delivery.Status)
+    assert.Equal(t, 500, delivery.ResponseStatus)
+    assert.Equal(t, 1, delivery.Attempts)
+    
+    dispatchWebhookDeliveries(now.Add(outboxRetryBase + time.Second))
+    db.First(&delivery)
+    assert.Equal(t, DeliveryDelivered, delivery.Status)
+    assert.Equal(t, 200, delivery.ResponseStatus)
+    assert.Equal(t, 2, delivery.Attempts)
+    
+    path := fmt.Sprintf("/api/webhooks/%d/deliveries", subscription.ID)
+    w := performRequest(router, "GET", path, tokens["admin"], nil)
+    assert.Equal(t, 200, w.Code)
+    var log []WebhookDelivery
+    json.Unmarshal(w.Body.Bytes(), &log)
+    assert.Len(t, log, 1)
+    
+    w = performRequest(router, "POST", fmt.Sprintf("%s/%d/redeliver", path, delivery.ID), tokens["admin"], nil)
+    assert.Equal(t, 202, w.Code)
+    dispatchWebhookDeliveries(time.Now())
+    
+    calls := received()
+    assert.Len(t, calls, 3)
+    assert.Equal(t, string(calls[1].Body), string(calls[2].Body))
+    assert.NotEqual(t, calls[1].Header.Get("X-Webhook-Delivery"), calls[2].Header.Get("X-Webhook-Delivery"))
+    
+    w = performRequest(router, "GET", path+"?status=delivered", tokens["admin"], nil)
+    json.Unmarshal(w.Body.Bytes(), &log)
+    assert.Len(t, log, 2)
+}